@@ -23,6 +23,10 @@ func setupLogging() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "merge" {
+		os.Exit(runMerge(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	setupLogging()
 
 	web.Start("8080")
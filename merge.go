@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	sealedsecret "github.com/atom363/sealed-secrets-ui/sealed-secret"
+)
+
+const mergeUsage = "usage: sealed-secrets-ui merge <base.yaml> <ours.yaml> <theirs.yaml>"
+
+// runMerge merges the given manifests and writes the result to stdout and the
+// conflict report to stderr. The exit code is non-zero while conflicts remain.
+func runMerge(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 3 {
+		fmt.Fprintln(stderr, mergeUsage)
+		return 2
+	}
+
+	manifests := make([]string, 0, len(args))
+	for _, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		manifests = append(manifests, string(data))
+	}
+
+	result, err := sealedsecret.MergeManifests(model.MergeOpts{
+		Base:   manifests[0],
+		Ours:   manifests[1],
+		Theirs: manifests[2],
+	})
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	fmt.Fprint(stdout, result.Manifest)
+
+	for _, conflict := range result.Conflicts {
+		fmt.Fprintf(stderr, "conflict: %s: %s changed on both sides\n", conflict.Field, conflict.Key)
+	}
+
+	if len(result.Conflicts) > 0 {
+		return 1
+	}
+
+	return 0
+}
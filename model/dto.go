@@ -1,8 +1,293 @@
 package model
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 type CreateOpts struct {
 	Scope      string
+	Mode       string
 	Namespace  string
 	SecretName string
-	Values     map[string]string
+	// Values are raw bytes, so binary files can be sealed as they are.
+	Values map[string][]byte
+	// Type, Labels, Annotations and Immutable are set on the template of the
+	// generated Secret, SealedSecretLabels on the SealedSecret itself.
+	Type               string
+	Labels             map[string]string
+	Annotations        map[string]string
+	Immutable          bool
+	SealedSecretLabels map[string]string
+	TemplateData       map[string]string
+	// Managed, Patch and SkipSetOwnerReferences set the sealed-secrets
+	// behaviour annotations on the template.
+	Managed                bool
+	Patch                  bool
+	SkipSetOwnerReferences bool
+	// ReplaceTemplate replaces the type, labels, data, immutability and
+	// behaviour annotations of the existing template with the given ones, so
+	// options that are not given clear them. Otherwise they are added to the
+	// existing template.
+	ReplaceTemplate bool
+	// Manifest is an existing SealedSecret manifest to extend. When set, its
+	// name, namespace and scope are used and the existing objects are not
+	// read.
+	Manifest string
+	// Certificate is the PEM certificate of the controller, as printed by
+	// kubeseal --fetch-cert. When set, the public key is not fetched from the
+	// controller, so a manifest is extended without the cluster.
+	Certificate string
+	// Output is either "manifest" (default) or "patch".
+	Output string
+	// ConfirmScopeChange allows sealing with another scope than the existing
+	// SealedSecret.
+	ConfirmScopeChange bool
+	// AcknowledgeWarnings allows sealing values with lint warnings.
+	AcknowledgeWarnings bool
+	// Generators generate random values for keys that are not submitted.
+	Generators []Generator
+}
+
+// Generator generates a random value for a key with crypto/rand.
+type Generator struct {
+	Key string
+	// Kind is "password", "hex", "base64", "uuid", "jwt", "ssh-ed25519",
+	// "ssh-rsa" or "tls".
+	Kind string
+	// Length is the number of characters of a password, of random bytes of a
+	// token, of bits of an RSA key or of days a certificate is valid, zero for
+	// the default.
+	Length int
+	// Classes are the character classes of a password: "lower", "upper",
+	// "digits" and "symbols", all of them when empty.
+	Classes []string
+	// Subject is the comment of an SSH key or the common name of a
+	// certificate.
+	Subject string
+	// SANs are the DNS names and IP addresses of a certificate.
+	SANs []string
+	// Reveal returns the generated value once with the result. The private
+	// keys of key pairs are never returned.
+	Reveal bool
+}
+
+type CreateResult struct {
+	Manifest        string
+	DroppedKeys     []string
+	MetadataChanges []MetadataChange
+	SecretKeys      []SecretKey
+	// MarkedManaged is set when the live Secret was annotated to be taken over
+	// by the controller.
+	MarkedManaged bool
+	// ScopeChange is set when the scope of the existing SealedSecret changed.
+	ScopeChange *ScopeChange
+	// LintWarnings are the acknowledged lint warnings of the values.
+	LintWarnings []LintIssue
+	// RevealedValues are the generated values that were asked to be revealed.
+	RevealedValues map[string]string
+	// PublicValues are the public keys and certificates of the generated key
+	// pairs by file name.
+	PublicValues map[string]string
+	// KeptRegistries are the registries of the existing image pull secret that
+	// were kept next to the submitted ones.
+	KeptRegistries []string
+}
+
+type ScopeChange struct {
+	From string
+	To   string
+}
+
+// ScopeChangeError is returned when the scope of the existing SealedSecret
+// would change without confirmation.
+type ScopeChangeError struct {
+	ScopeChange
+}
+
+func (e ScopeChangeError) Error() string {
+	return fmt.Sprintf("the existing sealed secret uses the %s scope, changing it to %s has to be confirmed", e.From, e.To)
+}
+
+// CloneOpts select a source Secret to seal again for another namespace and/or
+// name. Keys limits the cloned keys, Renames maps source keys to new names.
+type CloneOpts struct {
+	SourceNamespace  string
+	SourceSecretName string
+	Scope            string
+	Namespace        string
+	SecretName       string
+	Keys             []string
+	Renames          map[string]string
+	// CopyTemplate copies the template metadata of the source SealedSecret.
+	CopyTemplate       bool
+	ConfirmScopeChange bool
+}
+
+// AdoptOpts select an existing plain Secret to seal. MarkManaged annotates the
+// live Secret, so the controller takes ownership of it.
+type AdoptOpts struct {
+	Scope              string
+	Namespace          string
+	SecretName         string
+	MarkManaged        bool
+	ConfirmScopeChange bool
+}
+
+// RegistryOpts select the image pull secret to seal with the credentials of
+// the given registries.
+type RegistryOpts struct {
+	Scope              string
+	Namespace          string
+	SecretName         string
+	Registries         []RegistryCredential
+	ConfirmScopeChange bool
+}
+
+// RegistryCredential is the login of a container registry.
+type RegistryCredential struct {
+	Server   string
+	Username string
+	Password string
+	Email    string
+}
+
+// SecretKey is a key of the Secret the controller generates, either from the
+// encrypted data or from a template.
+type SecretKey struct {
+	Name      string
+	Templated bool
+}
+
+// MetadataChange is metadata of the existing SealedSecret that the generated
+// manifest changes or removes. New is empty for removed metadata.
+type MetadataChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+type MergeOpts struct {
+	Base   string
+	Ours   string
+	Theirs string
+}
+
+type MergeResult struct {
+	Manifest  string
+	Conflicts []MergeConflict
+}
+
+// MergeConflict is a key that was changed differently on both sides. For
+// encrypted data the key has to be sealed again.
+type MergeConflict struct {
+	Field string
+	Key   string
+}
+
+// PreservedMetadata is the resolved set of annotations and labels that are
+// copied from the existing SealedSecret, along with the rules selecting them.
+type PreservedMetadata struct {
+	AnnotationRules []string
+	LabelRules      []string
+	Annotations     map[string]string
+	Labels          map[string]string
+}
+
+// SecretDetails describe the existing SealedSecret and Secret without their
+// values. The template fields are used to pre-fill the form. Warnings name the
+// objects that could not be read.
+type SecretDetails struct {
+	SealedSecret           *ObjectDetails
+	Secret                 *ObjectDetails
+	Scope                  string
+	Type                   string
+	Labels                 map[string]string
+	SealedSecretLabels     map[string]string
+	TemplateData           map[string]string
+	Immutable              bool
+	Managed                bool
+	Patch                  bool
+	SkipSetOwnerReferences bool
+	Warnings               []string
+}
+
+// ObjectDetails are the key names, owners and last update time of an object.
+type ObjectDetails struct {
+	Keys            []string
+	OwnerReferences []string
+	LastUpdated     time.Time
+}
+
+// PreviewResult describes what sealing the submitted values would change,
+// without producing the manifest.
+type PreviewResult struct {
+	Keys            []KeyChange
+	MetadataChanges []MetadataChange
+	ScopeChange     *ScopeChange
+	LintIssues      []LintIssue
+}
+
+// KeyChange classifies a key of the sealed secret. Status is one of "added",
+// "changed", "unchanged", "overwritten" or "removed". Overwritten keys exist
+// but their value could not be compared, because the Secret was not read.
+type KeyChange struct {
+	Name   string
+	Status string
+}
+
+// FieldError is a problem with the value of a single field.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError lists the fields that the Kubernetes API would reject.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldError := range e.Errors {
+		messages = append(messages, fieldError.Field+": "+fieldError.Message)
+	}
+
+	return "invalid fields: " + strings.Join(messages, "; ")
+}
+
+// LintIssue is a problem with a value, such as a placeholder or malformed
+// PEM. Issues that are errors by policy cannot be acknowledged.
+type LintIssue struct {
+	Key     string
+	Rule    string
+	Message string
+	Error   bool
+}
+
+// LintError is returned when values have lint issues that are errors or
+// warnings that were not acknowledged.
+type LintError struct {
+	Issues []LintIssue
+}
+
+func (e LintError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		messages = append(messages, issue.Key+": "+issue.Message)
+	}
+
+	return "values have lint issues: " + strings.Join(messages, "; ")
+}
+
+// HasErrors reports whether any of the issues is an error by policy.
+func (e LintError) HasErrors() bool {
+	for _, issue := range e.Issues {
+		if issue.Error {
+			return true
+		}
+	}
+
+	return false
 }
@@ -3,6 +3,7 @@ package model
 type Metadata struct {
 	Name        string            `yaml:"name"`
 	Namespace   string            `yaml:"namespace"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
 	Annotations map[string]string `yaml:"annotations,omitempty"`
 }
 
@@ -12,7 +13,15 @@ type SealedSecretSpec struct {
 }
 
 type Template struct {
-	Metadata Metadata `yaml:"metadata,omitempty"`
+	Metadata  Metadata `yaml:"metadata,omitempty"`
+	Type      string   `yaml:"type,omitempty"`
+	Immutable bool     `yaml:"immutable,omitempty"`
+	// Data holds Go templates that are rendered with the decrypted values into
+	// non-secret keys of the generated Secret.
+	Data map[string]string `yaml:"data,omitempty"`
+	// Rest keeps the template fields that are not modelled above, so they
+	// survive a round trip through the model.
+	Rest map[string]interface{} `yaml:",inline"`
 }
 
 type SealedSecret struct {
@@ -21,3 +30,28 @@ type SealedSecret struct {
 	Metadata   Metadata         `yaml:"metadata"`
 	Spec       SealedSecretSpec `yaml:"spec"`
 }
+
+// SealedSecretPatch is a merge patch for a SealedSecret. Removed keys and
+// annotations are represented by nil values, which are rendered as null.
+type SealedSecretPatch struct {
+	APIVersion string                `yaml:"apiVersion"`
+	Kind       string                `yaml:"kind"`
+	Metadata   PatchMetadata         `yaml:"metadata"`
+	Spec       SealedSecretPatchSpec `yaml:"spec"`
+}
+
+type PatchMetadata struct {
+	Name        string             `yaml:"name"`
+	Namespace   string             `yaml:"namespace"`
+	Annotations map[string]*string `yaml:"annotations,omitempty"`
+}
+
+type SealedSecretPatchSpec struct {
+	EncryptedData map[string]*string `yaml:"encryptedData"`
+	Template      PatchTemplate      `yaml:"template"`
+}
+
+type PatchTemplate struct {
+	Metadata PatchMetadata     `yaml:"metadata,omitempty"`
+	Data     map[string]string `yaml:"data,omitempty"`
+}
@@ -0,0 +1,86 @@
+package sealedsecret
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// AdoptSecret seals all data of an existing plain Secret and copies its type,
+// labels and non-system annotations into the template.
+func (s SealedSecretService) AdoptSecret(ctx context.Context, opts model.AdoptOpts) (model.CreateResult, error) {
+	secret, err := s.getSecret(ctx, opts.Namespace, opts.SecretName)
+	if err != nil {
+		return model.CreateResult{}, fmt.Errorf("failed to get secret: %w", err)
+	}
+	if secret == nil {
+		return model.CreateResult{}, fmt.Errorf("secret %s/%s does not exist", opts.Namespace, opts.SecretName)
+	}
+
+	createOpts, err := getAdoptCreateOpts(secret, opts)
+	if err != nil {
+		return model.CreateResult{}, err
+	}
+
+	result, err := s.CreateSealedSecret(ctx, createOpts)
+	if err != nil {
+		return model.CreateResult{}, err
+	}
+
+	if opts.MarkManaged {
+		if err := s.markSecretManaged(ctx, opts.Namespace, opts.SecretName); err != nil {
+			return model.CreateResult{}, fmt.Errorf("failed to mark secret as managed: %w", err)
+		}
+		result.MarkedManaged = true
+	}
+
+	return result, nil
+}
+
+func getAdoptCreateOpts(secret *corev1.Secret, opts model.AdoptOpts) (model.CreateOpts, error) {
+	if secret.Type == corev1.SecretTypeServiceAccountToken {
+		return model.CreateOpts{}, fmt.Errorf("service account tokens are managed by Kubernetes and cannot be adopted")
+	}
+
+	values := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+	for key, value := range secret.Data {
+		values[key] = value
+	}
+	for key, value := range secret.StringData {
+		values[key] = []byte(value)
+	}
+
+	if len(values) == 0 {
+		return model.CreateOpts{}, fmt.Errorf("secret %s/%s has no data", opts.Namespace, opts.SecretName)
+	}
+
+	annotations := make(map[string]string)
+	for key, value := range secret.Annotations {
+		if isSystemAnnotation(key) || isScopeAnnotation(key) {
+			continue
+		}
+		annotations[key] = value
+	}
+
+	secretType := string(secret.Type)
+	if secret.Type == corev1.SecretTypeOpaque {
+		secretType = ""
+	}
+
+	return model.CreateOpts{
+		Scope:              opts.Scope,
+		Mode:               "replace",
+		Namespace:          opts.Namespace,
+		SecretName:         opts.SecretName,
+		Values:             values,
+		Type:               secretType,
+		Labels:             secret.Labels,
+		Annotations:        annotations,
+		Immutable:          secret.Immutable != nil && *secret.Immutable,
+		ConfirmScopeChange: opts.ConfirmScopeChange,
+		// the values are already in use, so their warnings are only reported
+		AcknowledgeWarnings: true,
+	}, nil
+}
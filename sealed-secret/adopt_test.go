@@ -0,0 +1,57 @@
+package sealedsecret
+
+import (
+	"testing"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetAdoptCreateOpts(t *testing.T) {
+	immutable := true
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "registry",
+			Namespace: "default",
+			Labels:    map[string]string{"team": "payments"},
+			Annotations: map[string]string{
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+				"meta.helm.sh/release-name":                        "app",
+				"reloader.stakater.com/match":                      "true",
+			},
+		},
+		Type:      corev1.SecretTypeDockerConfigJson,
+		Immutable: &immutable,
+		Data:      map[string][]byte{".dockerconfigjson": []byte(`{"auths":{}}`)},
+	}
+
+	got, err := getAdoptCreateOpts(secret, model.AdoptOpts{Scope: "strict", Namespace: "default", SecretName: "registry"})
+	require.NoError(t, err)
+
+	assert.Equal(t, model.CreateOpts{
+		Scope:       "strict",
+		Mode:        "replace",
+		Namespace:   "default",
+		SecretName:  "registry",
+		Values:      map[string][]byte{".dockerconfigjson": []byte(`{"auths":{}}`)},
+		Type:        "kubernetes.io/dockerconfigjson",
+		Labels:      map[string]string{"team": "payments"},
+		Annotations: map[string]string{"reloader.stakater.com/match": "true"},
+		Immutable:   true,
+
+		AcknowledgeWarnings: true,
+	}, got)
+}
+
+func TestGetAdoptCreateOptsRejectsServiceAccountTokens(t *testing.T) {
+	secret := &corev1.Secret{
+		Type: corev1.SecretTypeServiceAccountToken,
+		Data: map[string][]byte{"token": []byte("token")},
+	}
+
+	_, err := getAdoptCreateOpts(secret, model.AdoptOpts{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,115 @@
+package sealedsecret
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+)
+
+// CloneSecret seals the data of a source Secret for another namespace and/or
+// name. Keys can be filtered and renamed on the way.
+func (s SealedSecretService) CloneSecret(ctx context.Context, opts model.CloneOpts) (model.CreateResult, error) {
+	if opts.SourceNamespace == opts.Namespace && opts.SourceSecretName == opts.SecretName {
+		return model.CreateResult{}, fmt.Errorf("source and target secret are the same")
+	}
+
+	sourceData, err := s.getSecretData(ctx, opts.SourceNamespace, opts.SourceSecretName)
+	if err != nil {
+		return model.CreateResult{}, fmt.Errorf("failed to get source secret data: %w", err)
+	}
+	if sourceData == nil {
+		return model.CreateResult{}, fmt.Errorf("secret %s/%s does not exist", opts.SourceNamespace, opts.SourceSecretName)
+	}
+
+	values, err := selectValues(sourceData, opts.Keys, opts.Renames)
+	if err != nil {
+		return model.CreateResult{}, err
+	}
+
+	createOpts := model.CreateOpts{
+		Scope:              opts.Scope,
+		Mode:               "replace",
+		Namespace:          opts.Namespace,
+		SecretName:         opts.SecretName,
+		Values:             values,
+		ConfirmScopeChange: opts.ConfirmScopeChange,
+		// the values are already in use, so their warnings are only reported
+		AcknowledgeWarnings: true,
+	}
+
+	if opts.CopyTemplate {
+		sourceSealedSecret, err := s.getSealedSecret(ctx, opts.SourceNamespace, opts.SourceSecretName)
+		if err != nil {
+			return model.CreateResult{}, fmt.Errorf("failed to get source sealed secret: %w", err)
+		}
+		if sourceSealedSecret != nil {
+			copyTemplateMetadata(&createOpts, sourceSealedSecret.Spec.Template)
+		}
+	}
+
+	return s.CreateSealedSecret(ctx, createOpts)
+}
+
+// selectValues filters the source data by the given keys, all keys are
+// selected when none are given, and renames them.
+func selectValues(source map[string][]byte, keys []string, renames map[string]string) (map[string][]byte, error) {
+	selected := source
+	if len(keys) > 0 {
+		selected = make(map[string][]byte, len(keys))
+		for _, key := range keys {
+			value, ok := source[key]
+			if !ok {
+				return nil, model.ValidationError{Errors: []model.FieldError{{Field: "keys", Message: fmt.Sprintf("source secret has no key %s", key)}}}
+			}
+			selected[key] = value
+		}
+	}
+
+	results := make(map[string][]byte, len(selected))
+	collisions := []string{}
+	for key, value := range selected {
+		newKey := key
+		if renamed, ok := renames[key]; ok {
+			newKey = renamed
+		}
+
+		if _, ok := results[newKey]; ok {
+			collisions = append(collisions, newKey)
+		}
+		results[newKey] = value
+	}
+
+	for key := range renames {
+		if _, ok := selected[key]; !ok {
+			return nil, model.ValidationError{Errors: []model.FieldError{{Field: "renames", Message: fmt.Sprintf("cannot rename %s, it is not a selected key of the source secret", key)}}}
+		}
+	}
+
+	if len(collisions) > 0 {
+		sort.Strings(collisions)
+		return nil, model.ValidationError{Errors: []model.FieldError{{Field: "renames", Message: fmt.Sprintf("renaming results in duplicated key(s) %s", strings.Join(collisions, ", "))}}}
+	}
+
+	return results, nil
+}
+
+// copyTemplateMetadata copies the type, labels, annotations and immutability
+// of a template. The scope annotations are left out, the clone is sealed with
+// its own scope.
+func copyTemplateMetadata(opts *model.CreateOpts, template model.Template) {
+	annotations := make(map[string]string, len(template.Metadata.Annotations))
+	for key, value := range template.Metadata.Annotations {
+		if isScopeAnnotation(key) {
+			continue
+		}
+		annotations[key] = value
+	}
+
+	opts.Type = template.Type
+	opts.Labels = template.Metadata.Labels
+	opts.Annotations = annotations
+	opts.Immutable = template.Immutable
+}
@@ -0,0 +1,65 @@
+package sealedsecret
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectValues(t *testing.T) {
+	source := map[string][]byte{
+		"API_TOKEN":   []byte("token"),
+		"PG_PASSWORD": []byte("password"),
+		"PG_USER":     []byte("user"),
+	}
+
+	tcs := []struct {
+		name      string
+		keys      []string
+		renames   map[string]string
+		want      map[string][]byte
+		isWantErr bool
+	}{
+		{
+			name: "all keys",
+			want: source,
+		},
+		{
+			name:    "filtered and renamed",
+			keys:    []string{"PG_PASSWORD", "PG_USER"},
+			renames: map[string]string{"PG_PASSWORD": "DATABASE_PASSWORD"},
+			want: map[string][]byte{
+				"DATABASE_PASSWORD": []byte("password"),
+				"PG_USER":           []byte("user"),
+			},
+		},
+		{
+			name:      "unknown key",
+			keys:      []string{"MISSING"},
+			isWantErr: true,
+		},
+		{
+			name:      "rename of a key that is not selected",
+			keys:      []string{"PG_USER"},
+			renames:   map[string]string{"API_TOKEN": "TOKEN"},
+			isWantErr: true,
+		},
+		{
+			name:      "rename collision",
+			renames:   map[string]string{"PG_PASSWORD": "PG_USER"},
+			isWantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := selectValues(source, tc.keys, tc.renames)
+			if tc.isWantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
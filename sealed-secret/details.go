@@ -0,0 +1,117 @@
+package sealedsecret
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetSecretDetails describes the existing SealedSecret and Secret without
+// reading out any value. Objects that cannot be read for lack of permissions
+// are reported as warnings instead of failing.
+func (s SealedSecretService) GetSecretDetails(ctx context.Context, namespace, secretName string) (model.SecretDetails, error) {
+	details := model.SecretDetails{}
+	if namespace == "" || secretName == "" {
+		return details, nil
+	}
+
+	obj, err := s.dynamicClient.Resource(sealedSecretGVR).Namespace(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsForbidden(err):
+		details.Warnings = append(details.Warnings, "Not allowed to read SealedSecrets in this namespace")
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return details, fmt.Errorf("failed to get sealed secret: %w", err)
+	default:
+		sealedSecret, err := sealedSecretFromUnstructured(obj)
+		if err != nil {
+			return details, err
+		}
+
+		applySealedSecretDetails(&details, sealedSecret)
+		details.SealedSecret = &model.ObjectDetails{
+			Keys:            sortedKeys(sealedSecret.Spec.EncryptedData),
+			OwnerReferences: formatOwnerReferences(obj.GetOwnerReferences()),
+			LastUpdated:     lastUpdated(obj.GetCreationTimestamp(), obj.GetManagedFields()),
+		}
+	}
+
+	secret, err := s.getSecret(ctx, namespace, secretName)
+	switch {
+	case apierrors.IsForbidden(err):
+		details.Warnings = append(details.Warnings, "Not allowed to read Secrets in this namespace")
+	case err != nil:
+		return details, fmt.Errorf("failed to get secret: %w", err)
+	case secret != nil:
+		if details.SealedSecret == nil {
+			applySecretDetails(&details, secret)
+		}
+		details.Managed = secret.Annotations[managedAnnotation] == "true"
+
+		details.Secret = &model.ObjectDetails{
+			Keys:            sortedKeys(secret.Data),
+			OwnerReferences: formatOwnerReferences(secret.OwnerReferences),
+			LastUpdated:     lastUpdated(secret.CreationTimestamp, secret.ManagedFields),
+		}
+	}
+
+	return details, nil
+}
+
+// applySealedSecretDetails takes the form fields from the SealedSecret and its
+// template.
+func applySealedSecretDetails(details *model.SecretDetails, sealedSecret model.SealedSecret) {
+	template := sealedSecret.Spec.Template
+	annotations := template.Metadata.Annotations
+
+	details.Scope = getScope(sealedSecret)
+	details.Type = template.Type
+	details.Labels = template.Metadata.Labels
+	details.SealedSecretLabels = sealedSecret.Metadata.Labels
+	details.TemplateData = template.Data
+	details.Immutable = template.Immutable
+	details.Patch = annotations[patchAnnotation] == "true"
+	details.SkipSetOwnerReferences = annotations[skipSetOwnerReferencesAnnotation] == "true"
+}
+
+// applySecretDetails takes the form fields from a Secret that has no
+// SealedSecret yet.
+func applySecretDetails(details *model.SecretDetails, secret *corev1.Secret) {
+	if secret.Type != corev1.SecretTypeOpaque {
+		details.Type = string(secret.Type)
+	}
+
+	details.Labels = secret.Labels
+	details.Immutable = secret.Immutable != nil && *secret.Immutable
+}
+
+func formatOwnerReferences(ownerReferences []metav1.OwnerReference) []string {
+	results := make([]string, 0, len(ownerReferences))
+	for _, ownerReference := range ownerReferences {
+		result := ownerReference.Kind + "/" + ownerReference.Name
+		if ownerReference.Controller != nil && *ownerReference.Controller {
+			result += " (controller)"
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// lastUpdated returns the latest time a field manager wrote the object,
+// falling back to its creation time.
+func lastUpdated(created metav1.Time, managedFields []metav1.ManagedFieldsEntry) time.Time {
+	result := created.Time
+	for _, entry := range managedFields {
+		if entry.Time != nil && entry.Time.After(result) {
+			result = entry.Time.Time
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,101 @@
+package sealedsecret
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplySealedSecretDetails(t *testing.T) {
+	details := model.SecretDetails{}
+	applySealedSecretDetails(&details, model.SealedSecret{
+		Metadata: model.Metadata{
+			Labels:      map[string]string{"team": "payments"},
+			Annotations: map[string]string{"sealedsecrets.bitnami.com/namespace-wide": "true"},
+		},
+		Spec: model.SealedSecretSpec{
+			Template: model.Template{
+				Metadata: model.Metadata{
+					Labels:      map[string]string{"app": "api"},
+					Annotations: map[string]string{"sealedsecrets.bitnami.com/managed": "true"},
+				},
+				Type:      "kubernetes.io/tls",
+				Immutable: true,
+				Data:      map[string]string{"url": "{{ .host }}"},
+			},
+		},
+	})
+
+	assert.Equal(t, model.SecretDetails{
+		Scope:              "namespace",
+		Type:               "kubernetes.io/tls",
+		Labels:             map[string]string{"app": "api"},
+		SealedSecretLabels: map[string]string{"team": "payments"},
+		TemplateData:       map[string]string{"url": "{{ .host }}"},
+		Immutable:          true,
+	}, details)
+}
+
+func TestApplySecretDetails(t *testing.T) {
+	immutable := true
+	tcs := []struct {
+		name   string
+		secret corev1.Secret
+		want   model.SecretDetails
+	}{
+		{
+			name:   "opaque",
+			secret: corev1.Secret{Type: corev1.SecretTypeOpaque},
+			want:   model.SecretDetails{},
+		},
+		{
+			name: "typed",
+			secret: corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "api"}},
+				Type:       corev1.SecretTypeBasicAuth,
+				Immutable:  &immutable,
+			},
+			want: model.SecretDetails{
+				Type:      "kubernetes.io/basic-auth",
+				Labels:    map[string]string{"app": "api"},
+				Immutable: true,
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			details := model.SecretDetails{}
+			applySecretDetails(&details, &tc.secret)
+
+			assert.Equal(t, tc.want, details)
+		})
+	}
+}
+
+func TestFormatOwnerReferences(t *testing.T) {
+	controller := true
+	got := formatOwnerReferences([]metav1.OwnerReference{
+		{Kind: "SealedSecret", Name: "api", Controller: &controller},
+		{Kind: "Deployment", Name: "api"},
+	})
+
+	assert.Equal(t, []string{"SealedSecret/api (controller)", "Deployment/api"}, got)
+}
+
+func TestLastUpdated(t *testing.T) {
+	created := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	updated := metav1.NewTime(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	older := metav1.NewTime(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, created.Time, lastUpdated(created, nil))
+	assert.Equal(t, updated.Time, lastUpdated(created, []metav1.ManagedFieldsEntry{
+		{Time: &updated},
+		{Time: &older},
+		{},
+	}))
+}
@@ -10,7 +10,10 @@ import (
 	"encoding/binary"
 )
 
-func hybridEncrypt(pubKey *rsa.PublicKey, value, label string) (string, error) {
+// gcmTagSize is the size of the authentication tag AES-GCM appends.
+const gcmTagSize = 16
+
+func hybridEncrypt(pubKey *rsa.PublicKey, value []byte, label string) (string, error) {
 	// Generate a random AES key
 	aesKey := make([]byte, 32) // Using AES-256
 	if _, err := rand.Read(aesKey); err != nil {
@@ -33,7 +36,7 @@ func hybridEncrypt(pubKey *rsa.PublicKey, value, label string) (string, error) {
 	nonce := make([]byte, gcm.NonceSize())
 
 	// Encrypt the data using AES-GCM
-	cipherText := gcm.Seal(nil, nonce, []byte(value), nil)
+	cipherText := gcm.Seal(nil, nonce, value, nil)
 
 	// Encrypt the AES key using RSA-OAEP
 	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pubKey, aesKey, []byte(label))
@@ -53,3 +56,20 @@ func hybridEncrypt(pubKey *rsa.PublicKey, value, label string) (string, error) {
 
 	return encodedResult, nil
 }
+
+// sealedValueSize works out the size of the sealed value from the layout
+// hybridEncrypt writes. Ciphertext that does not follow it counts with its
+// own size.
+func sealedValueSize(ciphertext string) int {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(data) < 2 {
+		return len(ciphertext)
+	}
+
+	size := len(data) - 2 - int(binary.BigEndian.Uint16(data)) - gcmTagSize
+	if size < 0 {
+		return len(ciphertext)
+	}
+
+	return size
+}
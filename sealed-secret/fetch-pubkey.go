@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/atom363/sealed-secrets-ui/model"
 	"github.com/rs/zerolog/log"
 )
 
@@ -46,8 +47,26 @@ func (s SealedSecretService) getPublicKey(ctx context.Context) (*rsa.PublicKey,
 	if err != nil {
 		return nil, fmt.Errorf("failed to get public key: %w", err)
 	}
-	certPEM := []byte(kubeSealCert)
 
+	return parsePublicKey([]byte(kubeSealCert))
+}
+
+// resolvePublicKey returns the public key of the given certificate of the
+// controller, or fetches it from the controller when none is given.
+func (s SealedSecretService) resolvePublicKey(ctx context.Context, certificate string) (*rsa.PublicKey, error) {
+	if certificate == "" {
+		return s.getPublicKey(ctx)
+	}
+
+	pubKey, err := parsePublicKey([]byte(certificate))
+	if err != nil {
+		return nil, model.ValidationError{Errors: []model.FieldError{{Field: "certificate", Message: err.Error()}}}
+	}
+
+	return pubKey, nil
+}
+
+func parsePublicKey(certPEM []byte) (*rsa.PublicKey, error) {
 	// Decode the PEM certificate
 	block, _ := pem.Decode(certPEM)
 	if block == nil || block.Type != "CERTIFICATE" {
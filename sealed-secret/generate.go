@@ -0,0 +1,283 @@
+package sealedsecret
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+)
+
+var passwordClasses = map[string]string{
+	"lower":   "abcdefghijklmnopqrstuvwxyz",
+	"upper":   "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"digits":  "0123456789",
+	"symbols": "!#$%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+var passwordClassOrder = []string{"lower", "upper", "digits", "symbols"}
+
+const (
+	defaultPasswordLength = 32
+	minPasswordLength     = 8
+	maxPasswordLength     = 1024
+	defaultTokenBytes     = 32
+	minTokenBytes         = 16
+	maxTokenBytes         = 1024
+	// jwtSecretBytes is the key size of HS512, which also suits HS256 and
+	// HS384.
+	jwtSecretBytes = 64
+)
+
+// generateValues adds the generated values to a copy of the submitted values.
+// It returns the generated values that should be revealed and the public keys
+// and certificates of the generated key pairs by file name.
+func generateValues(opts *model.CreateOpts) (map[string]string, map[string]string, error) {
+	if len(opts.Generators) == 0 {
+		return nil, nil, nil
+	}
+
+	values := make(map[string][]byte, len(opts.Values)+len(opts.Generators))
+	for key, value := range opts.Values {
+		values[key] = value
+	}
+
+	errs := []model.FieldError{}
+	revealed := make(map[string]string)
+	public := make(map[string]string)
+	// the certificates generated in one request share their CA
+	var ca *certificateAuthority
+	for _, generator := range opts.Generators {
+		var generated map[string][]byte
+		var err error
+		if isKeyPair(generator.Kind) {
+			var publicParts map[string]string
+			generated, publicParts, err = generateKeyPair(generator, &ca)
+			for name, value := range publicParts {
+				public[name] = value
+			}
+		} else {
+			var value string
+			value, err = generateValue(generator)
+			generated = map[string][]byte{generator.Key: []byte(value)}
+			if generator.Reveal {
+				revealed[generator.Key] = value
+			}
+		}
+
+		if err != nil {
+			errs = append(errs, model.FieldError{Field: "generators", Message: fmt.Sprintf("key %q: %v", generator.Key, err)})
+			continue
+		}
+
+		for _, key := range sortedKeys(generated) {
+			if _, ok := values[key]; ok {
+				errs = append(errs, model.FieldError{Field: "generators", Message: fmt.Sprintf("key %q is already given or generated", key)})
+				continue
+			}
+			values[key] = generated[key]
+		}
+
+		if opts.Type == "" {
+			opts.Type = keyPairType(generator)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, model.ValidationError{Errors: errs}
+	}
+
+	opts.Values = values
+	return revealed, public, nil
+}
+
+// previewValues adds the generated values for a preview. Key pairs are slow
+// to generate, so their keys are added without values.
+func previewValues(opts *model.CreateOpts) error {
+	generators := opts.Generators
+	keyPairs := []model.Generator{}
+	opts.Generators = nil
+	for _, generator := range generators {
+		if isKeyPair(generator.Kind) {
+			keyPairs = append(keyPairs, generator)
+			continue
+		}
+		opts.Generators = append(opts.Generators, generator)
+	}
+
+	if _, _, err := generateValues(opts); err != nil {
+		return err
+	}
+	opts.Generators = generators
+
+	values := make(map[string][]byte, len(opts.Values)+len(keyPairs))
+	for key, value := range opts.Values {
+		values[key] = value
+	}
+
+	errs := []model.FieldError{}
+	withCA := true
+	for _, generator := range keyPairs {
+		for _, key := range keyPairKeys(generator, withCA) {
+			if _, ok := values[key]; ok {
+				errs = append(errs, model.FieldError{Field: "generators", Message: fmt.Sprintf("key %q is already given or generated", key)})
+				continue
+			}
+			values[key] = nil
+		}
+
+		if generator.Kind == "tls" {
+			withCA = false
+		}
+
+		if opts.Type == "" {
+			opts.Type = keyPairType(generator)
+		}
+	}
+
+	if len(errs) > 0 {
+		return model.ValidationError{Errors: errs}
+	}
+
+	opts.Values = values
+	return nil
+}
+
+// submittedValues leaves the generated values out of the values, they are
+// random and have nothing to lint.
+func submittedValues(opts model.CreateOpts) map[string][]byte {
+	generated := make(map[string]struct{}, len(opts.Generators))
+	withCA := true
+	for _, generator := range opts.Generators {
+		if !isKeyPair(generator.Kind) {
+			generated[generator.Key] = struct{}{}
+			continue
+		}
+
+		for _, key := range keyPairKeys(generator, withCA) {
+			generated[key] = struct{}{}
+		}
+
+		if generator.Kind == "tls" {
+			withCA = false
+		}
+	}
+
+	return withoutKeys(opts.Values, generated)
+}
+
+func generateValue(generator model.Generator) (string, error) {
+	switch generator.Kind {
+	case "password":
+		return generatePassword(generator.Length, generator.Classes)
+	case "hex":
+		token, err := randomBytes(generator.Length, defaultTokenBytes)
+		return hex.EncodeToString(token), err
+	case "base64":
+		token, err := randomBytes(generator.Length, defaultTokenBytes)
+		return base64.StdEncoding.EncodeToString(token), err
+	case "uuid":
+		return generateUUID()
+	case "jwt":
+		secret, err := randomBytes(generator.Length, jwtSecretBytes)
+		return base64.RawURLEncoding.EncodeToString(secret), err
+	default:
+		return "", fmt.Errorf("unknown generator %q", generator.Kind)
+	}
+}
+
+// generatePassword returns a password with at least one character of every
+// class, the other characters are drawn from all of them.
+func generatePassword(length int, classes []string) (string, error) {
+	if length == 0 {
+		length = defaultPasswordLength
+	}
+
+	if length < minPasswordLength || length > maxPasswordLength {
+		return "", fmt.Errorf("password length must be between %d and %d", minPasswordLength, maxPasswordLength)
+	}
+
+	if len(classes) == 0 {
+		classes = passwordClassOrder
+	}
+
+	alphabet := ""
+	password := make([]byte, 0, length)
+	for _, class := range classes {
+		characters, ok := passwordClasses[class]
+		if !ok {
+			return "", fmt.Errorf("unknown character class %q, expected one of %s", class, strings.Join(passwordClassOrder, ", "))
+		}
+		if strings.Contains(alphabet, characters) {
+			continue
+		}
+
+		c, err := randomCharacter(characters)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+		alphabet += characters
+	}
+
+	for len(password) < length {
+		c, err := randomCharacter(alphabet)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	// the first characters come from the classes in order, so shuffle them
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+
+	return string(password), nil
+}
+
+func randomCharacter(alphabet string) (byte, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+	if err != nil {
+		return 0, err
+	}
+
+	return alphabet[i.Int64()], nil
+}
+
+func randomBytes(length, defaultLength int) ([]byte, error) {
+	if length == 0 {
+		length = defaultLength
+	}
+
+	if length < minTokenBytes || length > maxTokenBytes {
+		return nil, fmt.Errorf("length must be between %d and %d bytes", minTokenBytes, maxTokenBytes)
+	}
+
+	value := make([]byte, length)
+	if _, err := rand.Read(value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// generateUUID returns a random UUID of version 4.
+func generateUUID() (string, error) {
+	uuid := make([]byte, 16)
+	if _, err := rand.Read(uuid); err != nil {
+		return "", err
+	}
+
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16]), nil
+}
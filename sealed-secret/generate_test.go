@@ -0,0 +1,175 @@
+package sealedsecret
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateValue(t *testing.T) {
+	tcs := []struct {
+		name      string
+		generator model.Generator
+		check     func(t *testing.T, value string)
+		wantErr   string
+	}{
+		{
+			name:      "default password",
+			generator: model.Generator{Kind: "password"},
+			check: func(t *testing.T, value string) {
+				assert.Len(t, value, defaultPasswordLength)
+				for _, class := range passwordClassOrder {
+					assert.True(t, strings.ContainsAny(value, passwordClasses[class]), class)
+				}
+			},
+		},
+		{
+			name:      "password with classes",
+			generator: model.Generator{Kind: "password", Length: 12, Classes: []string{"digits", "upper"}},
+			check: func(t *testing.T, value string) {
+				assert.Regexp(t, `^[0-9A-Z]{12}$`, value)
+				assert.True(t, strings.ContainsAny(value, passwordClasses["digits"]))
+				assert.True(t, strings.ContainsAny(value, passwordClasses["upper"]))
+			},
+		},
+		{
+			name:      "hex token",
+			generator: model.Generator{Kind: "hex", Length: 16},
+			check: func(t *testing.T, value string) {
+				decoded, err := hex.DecodeString(value)
+				require.NoError(t, err)
+				assert.Len(t, decoded, 16)
+			},
+		},
+		{
+			name:      "base64 token",
+			generator: model.Generator{Kind: "base64"},
+			check: func(t *testing.T, value string) {
+				decoded, err := base64.StdEncoding.DecodeString(value)
+				require.NoError(t, err)
+				assert.Len(t, decoded, defaultTokenBytes)
+			},
+		},
+		{
+			name:      "uuid",
+			generator: model.Generator{Kind: "uuid"},
+			check: func(t *testing.T, value string) {
+				assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), value)
+			},
+		},
+		{
+			name:      "jwt secret",
+			generator: model.Generator{Kind: "jwt"},
+			check: func(t *testing.T, value string) {
+				decoded, err := base64.RawURLEncoding.DecodeString(value)
+				require.NoError(t, err)
+				assert.Len(t, decoded, jwtSecretBytes)
+			},
+		},
+		{
+			name:      "short password",
+			generator: model.Generator{Kind: "password", Length: 4},
+			wantErr:   "password length must be between 8 and 1024",
+		},
+		{
+			name:      "unknown class",
+			generator: model.Generator{Kind: "password", Classes: []string{"emoji"}},
+			wantErr:   `unknown character class "emoji", expected one of lower, upper, digits, symbols`,
+		},
+		{
+			name:      "short token",
+			generator: model.Generator{Kind: "hex", Length: 8},
+			wantErr:   "length must be between 16 and 1024 bytes",
+		},
+		{
+			name:      "unknown kind",
+			generator: model.Generator{Kind: "pin"},
+			wantErr:   `unknown generator "pin"`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := generateValue(tc.generator)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			tc.check(t, got)
+		})
+	}
+}
+
+func TestGenerateValuesDiffer(t *testing.T) {
+	first, err := generateValue(model.Generator{Kind: "password"})
+	require.NoError(t, err)
+	second, err := generateValue(model.Generator{Kind: "password"})
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+}
+
+func TestGenerateValues(t *testing.T) {
+	values := map[string][]byte{"DB_USER": []byte("app")}
+	opts := model.CreateOpts{
+		Values: values,
+		Generators: []model.Generator{
+			{Key: "DB_PASSWORD", Kind: "password"},
+			{Key: "API_TOKEN", Kind: "hex", Reveal: true},
+		},
+	}
+
+	revealed, _, err := generateValues(&opts)
+	require.NoError(t, err)
+
+	assert.Len(t, values, 1, "the submitted values are not changed")
+	assert.Equal(t, []string{"API_TOKEN", "DB_PASSWORD", "DB_USER"}, sortedKeys(opts.Values))
+	assert.Equal(t, map[string]string{"API_TOKEN": string(opts.Values["API_TOKEN"])}, revealed)
+}
+
+func TestGenerateValuesConflicts(t *testing.T) {
+	opts := model.CreateOpts{
+		Values:     map[string][]byte{"DB_PASSWORD": []byte("secret")},
+		Generators: []model.Generator{{Key: "DB_PASSWORD", Kind: "password"}},
+	}
+
+	_, _, err := generateValues(&opts)
+
+	var validationErr model.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []model.FieldError{{Field: "generators", Message: `key "DB_PASSWORD" is already given or generated`}}, validationErr.Errors)
+}
+
+func TestPreviewValues(t *testing.T) {
+	opts := model.CreateOpts{
+		Values: map[string][]byte{"DB_USER": []byte("app")},
+		Generators: []model.Generator{
+			{Kind: "tls", Subject: "app.example.com"},
+			{Key: "DB_PASSWORD", Kind: "password"},
+			{Key: "client.crt", Kind: "tls", Subject: "client"},
+			{Key: "deploy-key", Kind: "ssh-ed25519"},
+		},
+	}
+
+	require.NoError(t, previewValues(&opts))
+
+	assert.Len(t, opts.Generators, 4, "the generators are kept for linting")
+	assert.Equal(t, []string{"DB_PASSWORD", "DB_USER", "ca.crt", "client.crt", "client.key", "deploy-key", "tls.crt", "tls.key"}, sortedKeys(opts.Values))
+	assert.Nil(t, opts.Values["tls.key"], "key pairs are not generated")
+	assert.Len(t, opts.Values["DB_PASSWORD"], defaultPasswordLength)
+	assert.Equal(t, "kubernetes.io/tls", opts.Type)
+
+	err := previewValues(&model.CreateOpts{
+		Values:     map[string][]byte{"tls.key": []byte("key")},
+		Generators: []model.Generator{{Kind: "tls", Subject: "app.example.com"}},
+	})
+	assert.Error(t, err)
+}
@@ -7,10 +7,13 @@ import (
 	"path/filepath"
 	"sort"
 
+	"github.com/atom363/sealed-secrets-ui/model"
 	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
@@ -25,6 +28,11 @@ var sealedSecretGVR = schema.GroupVersionResource{
 	Resource: "sealedsecrets",
 }
 
+var secretGVR = schema.GroupVersionResource{
+	Version:  "v1",
+	Resource: "secrets",
+}
+
 func getKubeconfigPath() string {
 	defaultPath := ""
 	if home := homedir.HomeDir(); home != "" {
@@ -54,25 +62,36 @@ func getClusterConfig() (*rest.Config, error) {
 	return config, nil
 }
 
-func decodeSecret(secretData map[string][]byte) map[string]string {
-	data := make(map[string]string)
-	for key, value := range secretData {
-		data[key] = string(value)
-	}
-
-	return data
-}
-
-func (s SealedSecretService) getSecretData(ctx context.Context, namespace, secretName string) (map[string]string, error) {
+// getSecret returns the live Secret or nil if it does not exist.
+func (s SealedSecretService) getSecret(ctx context.Context, namespace, secretName string) (*corev1.Secret, error) {
 	secret, err := s.k8sClient.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		log.Warn().Msg("Secret not found")
 		return nil, nil
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+func (s SealedSecretService) getSecretData(ctx context.Context, namespace, secretName string) (map[string][]byte, error) {
+	secret, err := s.getSecret(ctx, namespace, secretName)
+	if err != nil || secret == nil {
+		return nil, err
+	}
+
+	return secret.Data, nil
+}
 
-	data := decodeSecret(secret.Data)
+// markSecretManaged annotates the live Secret, so the controller takes it over
+// once the SealedSecret is applied.
+func (s SealedSecretService) markSecretManaged(ctx context.Context, namespace, secretName string) error {
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:"true"}}}`, managedAnnotation)
+	_, err := s.dynamicClient.Resource(secretGVR).Namespace(namespace).Patch(ctx, secretName, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
 
-	return data, nil
+	return err
 }
 
 func (s SealedSecretService) listNamespaces(ctx context.Context) ([]string, error) {
@@ -112,12 +131,9 @@ func (s SealedSecretService) listSecretNames(ctx context.Context, namespace stri
 	return results, nil
 }
 
-func (s SealedSecretService) getSealedSecretAnnotations(ctx context.Context, namespace, secretName string) (map[string]string, error) {
-	if len(s.annotationsToPreserve) == 0 {
-		return nil, nil
-	}
-
-	sealedSecret, err := s.dynamicClient.Resource(sealedSecretGVR).Namespace(namespace).Get(ctx, secretName, metav1.GetOptions{})
+// getSealedSecret returns the live SealedSecret or nil if it does not exist.
+func (s SealedSecretService) getSealedSecret(ctx context.Context, namespace, secretName string) (*model.SealedSecret, error) {
+	obj, err := s.dynamicClient.Resource(sealedSecretGVR).Namespace(namespace).Get(ctx, secretName, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		return nil, nil
 	}
@@ -125,17 +141,10 @@ func (s SealedSecretService) getSealedSecretAnnotations(ctx context.Context, nam
 		return nil, err
 	}
 
-	annotations := sealedSecret.GetAnnotations()
-	if len(annotations) == 0 {
-		return nil, nil
-	}
-
-	preserved := make(map[string]string, len(s.annotationsToPreserve))
-	for key := range s.annotationsToPreserve {
-		if value, ok := annotations[key]; ok {
-			preserved[key] = value
-		}
+	sealedSecret, err := sealedSecretFromUnstructured(obj)
+	if err != nil {
+		return nil, err
 	}
 
-	return preserved, nil
+	return &sealedSecret, nil
 }
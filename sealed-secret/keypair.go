@@ -0,0 +1,336 @@
+package sealedsecret
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+)
+
+const (
+	defaultSSHKey         = "ssh-privatekey"
+	defaultCertificateKey = "tls.crt"
+	caCertificateKey      = "ca.crt"
+
+	defaultRSABits      = 4096
+	minRSABits          = 2048
+	maxRSABits          = 8192
+	defaultValidDays    = 365
+	maxValidDays        = 3650
+	caValidityExtension = 365
+)
+
+// certificateAuthority signs the certificates generated in one request. Its
+// key is discarded afterwards.
+type certificateAuthority struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+	pem  []byte
+}
+
+func isKeyPair(kind string) bool {
+	return kind == "ssh-ed25519" || kind == "ssh-rsa" || kind == "tls"
+}
+
+// keyPairType returns the Secret type whose required keys the generator
+// fills, if any.
+func keyPairType(generator model.Generator) string {
+	switch {
+	case strings.HasPrefix(generator.Kind, "ssh-") && (generator.Key == "" || generator.Key == defaultSSHKey):
+		return "kubernetes.io/ssh-auth"
+	case generator.Kind == "tls":
+		if certKey, _ := certificateKeys(generator); certKey == defaultCertificateKey {
+			return "kubernetes.io/tls"
+		}
+		return ""
+	default:
+		return ""
+	}
+}
+
+// keyPairKeys returns the keys a key pair generator fills without generating
+// it. withCA adds the key of the CA certificate, which comes with the first
+// certificate of a request.
+func keyPairKeys(generator model.Generator, withCA bool) []string {
+	if generator.Kind != "tls" {
+		return []string{sshKey(generator)}
+	}
+
+	certKey, keyKey := certificateKeys(generator)
+	if withCA {
+		return []string{certKey, keyKey, caCertificateKey}
+	}
+
+	return []string{certKey, keyKey}
+}
+
+func sshKey(generator model.Generator) string {
+	if generator.Key == "" {
+		return defaultSSHKey
+	}
+
+	return generator.Key
+}
+
+// certificateKeys returns the keys of the certificate and its private key.
+// Both are derived from the key without its .crt or .key extension, so either
+// key of the pair names it.
+func certificateKeys(generator model.Generator) (string, string) {
+	key := generator.Key
+	if key == "" {
+		key = defaultCertificateKey
+	}
+
+	base, ok := strings.CutSuffix(key, ".crt")
+	if !ok {
+		base = strings.TrimSuffix(key, ".key")
+	}
+
+	return base + ".crt", base + ".key"
+}
+
+// generateKeyPair returns the private material to seal and the public key or
+// certificates to hand out.
+func generateKeyPair(generator model.Generator, ca **certificateAuthority) (map[string][]byte, map[string]string, error) {
+	if generator.Kind == "tls" {
+		return generateCertificate(generator, ca)
+	}
+
+	key := sshKey(generator)
+	privateKey, publicKey, err := generateSSHKey(generator.Kind, generator.Length, generator.Subject)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return map[string][]byte{key: privateKey}, map[string]string{key + ".pub": publicKey}, nil
+}
+
+// generateSSHKey returns the private key in the OpenSSH format and the public
+// key in the authorized_keys format.
+func generateSSHKey(kind string, bits int, comment string) ([]byte, string, error) {
+	var publicKey, privateKey []byte
+	switch kind {
+	case "ssh-ed25519":
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, "", err
+		}
+
+		publicKey = sshWire(sshString([]byte(kind)), sshString(public))
+		privateKey = sshWire(sshString([]byte(kind)), sshString(public), sshString(private))
+	case "ssh-rsa":
+		if bits == 0 {
+			bits = defaultRSABits
+		}
+		if bits < minRSABits || bits > maxRSABits {
+			return nil, "", fmt.Errorf("RSA keys must have between %d and %d bits", minRSABits, maxRSABits)
+		}
+
+		private, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, "", err
+		}
+
+		e := big.NewInt(int64(private.E))
+		publicKey = sshWire(sshString([]byte(kind)), sshMPInt(e), sshMPInt(private.N))
+		privateKey = sshWire(
+			sshString([]byte(kind)), sshMPInt(private.N), sshMPInt(e), sshMPInt(private.D),
+			sshMPInt(private.Precomputed.Qinv), sshMPInt(private.Primes[0]), sshMPInt(private.Primes[1]),
+		)
+	default:
+		return nil, "", fmt.Errorf("unknown key type %q", kind)
+	}
+
+	encoded, err := marshalOpenSSHPrivateKey(publicKey, privateKey, comment)
+	if err != nil {
+		return nil, "", err
+	}
+
+	authorizedKey := kind + " " + base64.StdEncoding.EncodeToString(publicKey)
+	if comment != "" {
+		authorizedKey += " " + comment
+	}
+
+	return encoded, authorizedKey + "\n", nil
+}
+
+// marshalOpenSSHPrivateKey encodes an unencrypted key in the format of
+// PROTOCOL.key of OpenSSH.
+func marshalOpenSSHPrivateKey(publicKey, privateKey []byte, comment string) ([]byte, error) {
+	var check [4]byte
+	if _, err := rand.Read(check[:]); err != nil {
+		return nil, err
+	}
+
+	private := sshWire(check[:], check[:], privateKey, sshString([]byte(comment)))
+	// the private section is padded to the block size of the "none" cipher
+	for i := byte(1); len(private)%8 != 0; i++ {
+		private = append(private, i)
+	}
+
+	body := sshWire(
+		[]byte("openssh-key-v1\x00"),
+		sshString([]byte("none")), sshString([]byte("none")), sshString(nil),
+		binary.BigEndian.AppendUint32(nil, 1),
+		sshString(publicKey),
+		sshString(private),
+	)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: body}), nil
+}
+
+func sshWire(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func sshString(value []byte) []byte {
+	return append(binary.BigEndian.AppendUint32(nil, uint32(len(value))), value...)
+}
+
+// sshMPInt encodes a non-negative integer, with a leading zero byte when the
+// high bit is set.
+func sshMPInt(value *big.Int) []byte {
+	encoded := value.Bytes()
+	if len(encoded) > 0 && encoded[0]&0x80 != 0 {
+		encoded = append([]byte{0}, encoded...)
+	}
+
+	return sshString(encoded)
+}
+
+// generateCertificate returns the leaf certificate and its key to seal. The CA
+// certificate is sealed as well and handed out with the leaf certificates.
+func generateCertificate(generator model.Generator, ca **certificateAuthority) (map[string][]byte, map[string]string, error) {
+	certKey, keyKey := certificateKeys(generator)
+
+	days := generator.Length
+	if days == 0 {
+		days = defaultValidDays
+	}
+	if days < 1 || days > maxValidDays {
+		return nil, nil, fmt.Errorf("certificates must be valid between 1 and %d days", maxValidDays)
+	}
+
+	if generator.Subject == "" && len(generator.SANs) == 0 {
+		return nil, nil, fmt.Errorf("a certificate needs a common name or subject alternative names")
+	}
+
+	newCA := *ca == nil
+	if newCA {
+		created, err := newCertificateAuthority(generator.Subject, days)
+		if err != nil {
+			return nil, nil, err
+		}
+		*ca = created
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template, err := newCertificateTemplate(generator.Subject, days)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	for _, san := range generator.SANs {
+		if ip := net.ParseIP(san); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, san)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, (*ca).cert, key.Public(), (*ca).key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	values := map[string][]byte{
+		certKey: cert,
+		keyKey:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	}
+	public := map[string]string{certKey: string(cert)}
+	// the CA certificate is added once, with its first certificate
+	if newCA {
+		values[caCertificateKey] = (*ca).pem
+		public[caCertificateKey] = string((*ca).pem)
+	}
+
+	return values, public, nil
+}
+
+func newCertificateAuthority(subject string, days int) (*certificateAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	commonName := "Sealed Secrets UI CA"
+	if subject != "" {
+		commonName = subject + " CA"
+	}
+
+	// the CA outlives its certificates, so they can be renewed before it expires
+	template, err := newCertificateTemplate(commonName, days+caValidityExtension)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &certificateAuthority{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}, nil
+}
+
+func newCertificateTemplate(commonName string, days int) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    now.Add(-5 * time.Minute),
+		NotAfter:     now.AddDate(0, 0, days),
+	}, nil
+}
@@ -0,0 +1,266 @@
+package sealedsecret
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSSHKey(t *testing.T) {
+	tcs := []struct {
+		kind string
+		bits int
+	}{
+		{kind: "ssh-ed25519"},
+		{kind: "ssh-rsa", bits: 2048},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.kind, func(t *testing.T) {
+			privateKey, publicKey, err := generateSSHKey(tc.kind, tc.bits, "deploy@example")
+			require.NoError(t, err)
+
+			fields := strings.Fields(publicKey)
+			require.Len(t, fields, 3)
+			assert.Equal(t, tc.kind, fields[0])
+			assert.Equal(t, "deploy@example", fields[2])
+
+			wire, err := base64.StdEncoding.DecodeString(fields[1])
+			require.NoError(t, err)
+			assert.True(t, bytes.HasPrefix(wire, sshString([]byte(tc.kind))))
+
+			publicBlob, signer := parseOpenSSHPrivateKey(t, privateKey, "deploy@example")
+			assert.Equal(t, wire, publicBlob, "the private key carries the key of the .pub line")
+
+			switch key := signer.(type) {
+			case ed25519.PrivateKey:
+				assert.Equal(t, sshWire(sshString([]byte(tc.kind)), sshString(key.Public().(ed25519.PublicKey))), wire)
+			case *rsa.PrivateKey:
+				e := big.NewInt(int64(key.E))
+				assert.Equal(t, sshWire(sshString([]byte(tc.kind)), sshMPInt(e), sshMPInt(key.N)), wire)
+			default:
+				t.Fatalf("unexpected key %T", signer)
+			}
+		})
+	}
+}
+
+// parseOpenSSHPrivateKey parses an unencrypted key in the format of
+// PROTOCOL.key of OpenSSH and returns its public key blob and private key.
+func parseOpenSSHPrivateKey(t *testing.T, data []byte, wantComment string) ([]byte, crypto.Signer) {
+	t.Helper()
+
+	block, rest := pem.Decode(data)
+	require.NotNil(t, block)
+	assert.Empty(t, rest)
+	require.Equal(t, "OPENSSH PRIVATE KEY", block.Type)
+
+	magic := []byte("openssh-key-v1\x00")
+	require.True(t, bytes.HasPrefix(block.Bytes, magic))
+	r := &sshReader{t: t, data: block.Bytes[len(magic):]}
+	assert.Equal(t, "none", string(r.string()), "cipher")
+	assert.Equal(t, "none", string(r.string()), "KDF")
+	assert.Empty(t, r.string(), "KDF options")
+	assert.Equal(t, uint32(1), r.uint32(), "number of keys")
+	publicBlob := r.string()
+	private := &sshReader{t: t, data: r.string()}
+	assert.Empty(t, r.data)
+
+	assert.Equal(t, private.uint32(), private.uint32(), "check integers")
+	kind := string(private.string())
+
+	var signer crypto.Signer
+	switch kind {
+	case "ssh-ed25519":
+		public := private.string()
+		key := ed25519.PrivateKey(private.string())
+		require.Len(t, key, ed25519.PrivateKeySize)
+		assert.Equal(t, ed25519.PublicKey(public), key.Public())
+		signer = key
+	case "ssh-rsa":
+		key := &rsa.PrivateKey{}
+		key.N = private.mpInt()
+		key.E = int(private.mpInt().Int64())
+		key.D = private.mpInt()
+		qinv := private.mpInt()
+		key.Primes = []*big.Int{private.mpInt(), private.mpInt()}
+		require.NoError(t, key.Validate())
+		key.Precompute()
+		assert.Equal(t, qinv, key.Precomputed.Qinv)
+		signer = key
+	default:
+		t.Fatalf("unexpected key type %q", kind)
+	}
+
+	assert.Equal(t, wantComment, string(private.string()))
+	for i, padding := range private.data {
+		assert.Equal(t, byte(i+1), padding, "padding")
+	}
+
+	return publicBlob, signer
+}
+
+type sshReader struct {
+	t    *testing.T
+	data []byte
+}
+
+func (r *sshReader) uint32() uint32 {
+	require.GreaterOrEqual(r.t, len(r.data), 4)
+	value := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+
+	return value
+}
+
+func (r *sshReader) string() []byte {
+	length := int(r.uint32())
+	require.GreaterOrEqual(r.t, len(r.data), length)
+	value := r.data[:length]
+	r.data = r.data[length:]
+
+	return value
+}
+
+func (r *sshReader) mpInt() *big.Int {
+	return new(big.Int).SetBytes(r.string())
+}
+
+func TestGenerateSSHKeyRejectsShortRSAKeys(t *testing.T) {
+	_, _, err := generateSSHKey("ssh-rsa", 1024, "")
+	require.EqualError(t, err, "RSA keys must have between 2048 and 8192 bits")
+}
+
+func TestGenerateValuesKeyPairs(t *testing.T) {
+	opts := model.CreateOpts{
+		Generators: []model.Generator{
+			{Kind: "tls", Subject: "api", SANs: []string{"api.payments.svc", "10.0.0.1"}},
+			{Key: "client.crt", Kind: "tls", Subject: "client"},
+		},
+	}
+
+	_, public, err := generateValues(&opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, "kubernetes.io/tls", opts.Type)
+	assert.Equal(t, []string{"ca.crt", "client.crt", "client.key", "tls.crt", "tls.key"}, sortedKeys(opts.Values))
+	assert.Equal(t, []string{"ca.crt", "client.crt", "tls.crt"}, sortedKeys(public))
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(opts.Values["ca.crt"]))
+
+	for _, name := range []string{"tls", "client"} {
+		pair, err := tls.X509KeyPair(opts.Values[name+".crt"], opts.Values[name+".key"])
+		require.NoError(t, err)
+
+		cert, err := x509.ParseCertificate(pair.Certificate[0])
+		require.NoError(t, err)
+		_, err = cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+		require.NoError(t, err, "both certificates are signed by the same CA")
+	}
+
+	cert, err := tls.X509KeyPair(opts.Values["tls.crt"], opts.Values["tls.key"])
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api.payments.svc"}, leaf.DNSNames)
+	assert.Equal(t, "10.0.0.1", leaf.IPAddresses[0].String())
+}
+
+func TestGenerateCertificateParses(t *testing.T) {
+	var ca *certificateAuthority
+	values, public, err := generateCertificate(model.Generator{Key: "api.key", Kind: "tls", Subject: "api"}, &ca)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"api.crt", "api.key", "ca.crt"}, sortedKeys(values))
+	assert.Equal(t, []string{"api.crt", "ca.crt"}, sortedKeys(public))
+
+	caBlock, _ := pem.Decode(values["ca.crt"])
+	require.NotNil(t, caBlock)
+	caCert, err := x509.ParseCertificate(caBlock.Bytes)
+	require.NoError(t, err)
+	assert.True(t, caCert.IsCA)
+
+	certBlock, _ := pem.Decode(values["api.crt"])
+	require.NotNil(t, certBlock)
+	assert.Equal(t, "CERTIFICATE", certBlock.Type)
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, "api", cert.Subject.CommonName)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	_, err = cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+	require.NoError(t, err)
+
+	keyBlock, _ := pem.Decode(values["api.key"])
+	require.NotNil(t, keyBlock)
+	assert.Equal(t, "PRIVATE KEY", keyBlock.Type)
+	key, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	require.NoError(t, err)
+	signer, ok := key.(crypto.Signer)
+	require.True(t, ok)
+	assert.True(t, cert.PublicKey.(*ecdsa.PublicKey).Equal(signer.Public()), "the key belongs to the certificate")
+}
+
+func TestCertificateKeys(t *testing.T) {
+	tcs := []struct {
+		key      string
+		wantCert string
+		wantKey  string
+		wantType string
+	}{
+		{key: "", wantCert: "tls.crt", wantKey: "tls.key", wantType: "kubernetes.io/tls"},
+		{key: "tls.crt", wantCert: "tls.crt", wantKey: "tls.key", wantType: "kubernetes.io/tls"},
+		{key: "tls.key", wantCert: "tls.crt", wantKey: "tls.key", wantType: "kubernetes.io/tls"},
+		{key: "client", wantCert: "client.crt", wantKey: "client.key"},
+		{key: "client.crt", wantCert: "client.crt", wantKey: "client.key"},
+		{key: "client.key", wantCert: "client.crt", wantKey: "client.key"},
+		{key: "client.key.crt", wantCert: "client.key.crt", wantKey: "client.key.key"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			generator := model.Generator{Key: tc.key, Kind: "tls"}
+			certKey, keyKey := certificateKeys(generator)
+
+			assert.Equal(t, tc.wantCert, certKey)
+			assert.Equal(t, tc.wantKey, keyKey)
+			assert.Equal(t, tc.wantType, keyPairType(generator))
+		})
+	}
+}
+
+func TestGenerateValuesSSHKeyPair(t *testing.T) {
+	opts := model.CreateOpts{
+		Generators: []model.Generator{{Kind: "ssh-ed25519", Subject: "deploy", Reveal: true}},
+	}
+
+	revealed, public, err := generateValues(&opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, "kubernetes.io/ssh-auth", opts.Type)
+	assert.Equal(t, []string{"ssh-privatekey"}, sortedKeys(opts.Values))
+	assert.Equal(t, []string{"ssh-privatekey.pub"}, sortedKeys(public))
+	assert.Empty(t, revealed, "private keys are never revealed")
+}
+
+func TestGenerateCertificateNeedsASubject(t *testing.T) {
+	var ca *certificateAuthority
+	_, _, err := generateCertificate(model.Generator{Kind: "tls"}, &ca)
+	require.EqualError(t, err, "a certificate needs a common name or subject alternative names")
+}
@@ -0,0 +1,255 @@
+package sealedsecret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+)
+
+// The lint rules, they can be turned into errors by policy.
+const (
+	lintPlaceholder = "placeholder"
+	lintWhitespace  = "whitespace"
+	lintWeak        = "weak"
+	lintPEM         = "pem"
+	lintJSON        = "json"
+	lintBase64      = "base64"
+	lintDuplicate   = "duplicate"
+)
+
+var lintRules = []string{lintPlaceholder, lintWhitespace, lintWeak, lintPEM, lintJSON, lintBase64, lintDuplicate}
+
+var placeholders = toStringSet([]string{
+	"changeme", "change-me", "change_me", "changeit", "replaceme", "replace-me", "replace_me",
+	"password", "secret", "todo", "tbd", "fixme", "placeholder", "example", "dummy", "test",
+	"null", "none", "nil", "undefined", "default",
+})
+
+var placeholderPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^<[^<>]*>$`),
+	regexp.MustCompile(`^\$\{[^{}]*\}$`),
+	regexp.MustCompile(`^\{\{[^{}]*\}\}$`),
+	regexp.MustCompile(`^(x{3,}|\*{3,}|\.{3,})$`),
+	regexp.MustCompile(`^your[-_ ].*[-_ ]here$`),
+}
+
+const (
+	// minPasswordEntropy is the estimated entropy in bits below which a
+	// password is reported as weak.
+	minPasswordEntropy = 60
+	// minDuplicateLength keeps short values such as "true" or port numbers
+	// from being reported as duplicates.
+	minDuplicateLength = 8
+)
+
+// validateLintRules checks that the policy only names known rules.
+func validateLintRules(rules []string) error {
+	for _, rule := range rules {
+		if !slices.Contains(lintRules, rule) {
+			return fmt.Errorf("unknown lint rule %q, expected one of %s", rule, strings.Join(lintRules, ", "))
+		}
+	}
+
+	return nil
+}
+
+// lintSecret checks the submitted values. Errors by policy always fail, the
+// warnings only when they are not acknowledged.
+func (s SealedSecretService) lintSecret(opts model.CreateOpts) ([]model.LintIssue, error) {
+	issues := lintValues(submittedValues(opts), s.lintErrors)
+	if len(issues) == 0 {
+		return nil, nil
+	}
+
+	lintErr := model.LintError{Issues: issues}
+	if lintErr.HasErrors() || !opts.AcknowledgeWarnings {
+		return nil, lintErr
+	}
+
+	return issues, nil
+}
+
+// lintValues reports the values that look like mistakes. The issues of rules
+// in errorRules are errors, the others warnings. Values are never part of
+// the messages.
+func lintValues(values map[string][]byte, errorRules map[string]struct{}) []model.LintIssue {
+	issues := []model.LintIssue{}
+	add := func(key, rule, message string) {
+		_, isError := errorRules[rule]
+		issues = append(issues, model.LintIssue{Key: key, Rule: rule, Message: message, Error: isError})
+	}
+
+	duplicates := duplicateKeys(values)
+	for _, key := range sortedKeys(values) {
+		value := values[key]
+		name := strings.ToLower(key)
+
+		if isPlaceholder(value) {
+			add(key, lintPlaceholder, "looks like a placeholder")
+		}
+
+		if hasSurroundingWhitespace(value) {
+			add(key, lintWhitespace, "has leading or trailing whitespace or newlines")
+		}
+
+		if isPasswordKey(name) && passwordEntropy(string(value)) < minPasswordEntropy {
+			add(key, lintWeak, "looks like a weak password")
+		}
+
+		if others, ok := duplicates[key]; ok {
+			add(key, lintDuplicate, "has the same value as "+strings.Join(others, ", "))
+		}
+
+		switch {
+		case isPEMKey(name) && !isValidPEM(value):
+			add(key, lintPEM, "is not a complete PEM block")
+		case isJSONKey(name) && !json.Valid(value):
+			add(key, lintJSON, "is not valid JSON")
+		case isBase64Key(name) && !isValidBase64(value):
+			add(key, lintBase64, "is not valid base64")
+		}
+	}
+
+	return issues
+}
+
+func isPlaceholder(value []byte) bool {
+	normalized := strings.ToLower(strings.TrimSpace(string(value)))
+	if _, ok := placeholders[normalized]; ok {
+		return true
+	}
+
+	for _, pattern := range placeholderPatterns {
+		if pattern.MatchString(normalized) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasSurroundingWhitespace reports leading or trailing whitespace. PEM
+// encoded values conventionally end with a single newline, which is fine.
+func hasSurroundingWhitespace(value []byte) bool {
+	if bytes.HasPrefix(bytes.TrimSpace(value), []byte("-----BEGIN ")) {
+		value = bytes.TrimSuffix(value, []byte("\n"))
+	}
+
+	return len(bytes.TrimSpace(value)) != len(value)
+}
+
+func isPasswordKey(name string) bool {
+	return strings.Contains(name, "pass") || strings.Contains(name, "pwd")
+}
+
+func isPEMKey(name string) bool {
+	for _, suffix := range []string{".pem", ".crt", ".cert", ".key"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	for _, part := range []string{"private_key", "private-key", "privatekey", "certificate"} {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isJSONKey(name string) bool {
+	return strings.HasSuffix(name, ".json") || name == ".dockerconfigjson"
+}
+
+func isBase64Key(name string) bool {
+	return strings.Contains(name, "base64") || strings.HasSuffix(name, ".b64")
+}
+
+// isValidPEM reports whether the value consists of complete PEM blocks only.
+func isValidPEM(value []byte) bool {
+	block, rest := pem.Decode(value)
+	if block == nil {
+		return false
+	}
+
+	for len(bytes.TrimSpace(rest)) > 0 {
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isValidBase64(value []byte) bool {
+	_, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(value)), ""))
+	return err == nil
+}
+
+// passwordEntropy estimates the entropy in bits from the length and the
+// character classes a password uses.
+func passwordEntropy(password string) float64 {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {other, 33}} {
+		if class.used {
+			pool += class.size
+		}
+	}
+
+	if pool == 0 {
+		return 0
+	}
+
+	return float64(len([]rune(password))) * math.Log2(float64(pool))
+}
+
+// duplicateKeys returns the other keys sharing the value of each key.
+func duplicateKeys(values map[string][]byte) map[string][]string {
+	keysByValue := make(map[string][]string)
+	for _, key := range sortedKeys(values) {
+		value := values[key]
+		if len(value) < minDuplicateLength {
+			continue
+		}
+		keysByValue[string(value)] = append(keysByValue[string(value)], key)
+	}
+
+	results := make(map[string][]string)
+	for _, keys := range keysByValue {
+		for _, key := range keys {
+			if len(keys) > 1 {
+				results[key] = slices.DeleteFunc(slices.Clone(keys), func(other string) bool { return other == key })
+			}
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,139 @@
+package sealedsecret
+
+import (
+	"fmt"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func parseSealedSecret(data []byte) (model.SealedSecret, error) {
+	var sealedSecret model.SealedSecret
+	if err := yaml.Unmarshal(data, &sealedSecret); err != nil {
+		return model.SealedSecret{}, fmt.Errorf("failed to parse sealed secret: %w", err)
+	}
+
+	if sealedSecret.Kind != "SealedSecret" {
+		return model.SealedSecret{}, fmt.Errorf("unexpected kind %q, expected SealedSecret", sealedSecret.Kind)
+	}
+
+	return sealedSecret, nil
+}
+
+// parseManifest parses a SealedSecret manifest pasted into the form.
+func parseManifest(manifest string) (model.SealedSecret, error) {
+	sealedSecret, err := parseSealedSecret([]byte(manifest))
+	if err != nil {
+		return model.SealedSecret{}, model.ValidationError{Errors: []model.FieldError{{Field: "manifest", Message: err.Error()}}}
+	}
+
+	if sealedSecret.Metadata.Name == "" || sealedSecret.Metadata.Namespace == "" {
+		return model.SealedSecret{}, model.ValidationError{Errors: []model.FieldError{{
+			Field:   "manifest",
+			Message: "the sealed secret manifest must have a name and a namespace",
+		}}}
+	}
+
+	return sealedSecret, nil
+}
+
+func sealedSecretFromUnstructured(obj *unstructured.Unstructured) (model.SealedSecret, error) {
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return model.SealedSecret{}, fmt.Errorf("failed to marshal sealed secret: %w", err)
+	}
+
+	return parseSealedSecret(data)
+}
+
+// getScope returns the scope the sealed secret was sealed with. The
+// controller reads the scope annotations from the SealedSecret metadata, the
+// template annotations are only consulted for manifests that lack them.
+func getScope(sealedSecret model.SealedSecret) string {
+	for _, annotations := range []map[string]string{sealedSecret.Metadata.Annotations, sealedSecret.Spec.Template.Metadata.Annotations} {
+		switch {
+		case annotations["sealedsecrets.bitnami.com/cluster-wide"] == "true":
+			return "cluster"
+		case annotations["sealedsecrets.bitnami.com/namespace-wide"] == "true":
+			return "namespace"
+		}
+	}
+
+	return "strict"
+}
+
+// renderManifest marshals the sealed secret to YAML. The "patch" output only
+// contains the newly encrypted keys, the dropped keys as nulls, the scope
+// annotations and the template data, so it can be applied on top of the
+// existing manifest. existingScope is the scope of the existing SealedSecret,
+// if there is one.
+func renderManifest(sealedSecret model.SealedSecret, newEncryptedData map[string]string, droppedKeys []string, output, existingScope string) (string, error) {
+	var manifest interface{} = sealedSecret
+	if output == "patch" {
+		manifest = newSealedSecretPatch(sealedSecret, newEncryptedData, droppedKeys, existingScope)
+	}
+
+	yamlData, err := yaml.Marshal(manifest)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal sealed secret to YAML: %w", err)
+	}
+
+	return string(yamlData), nil
+}
+
+func newSealedSecretPatch(sealedSecret model.SealedSecret, newEncryptedData map[string]string, droppedKeys []string, existingScope string) model.SealedSecretPatch {
+	encryptedData := make(map[string]*string, len(newEncryptedData)+len(droppedKeys))
+	for key, value := range newEncryptedData {
+		encryptedData[key] = &value
+	}
+
+	for _, key := range droppedKeys {
+		encryptedData[key] = nil
+	}
+
+	scopeAnnotations := getScopePatchAnnotations(getScope(sealedSecret), existingScope)
+
+	return model.SealedSecretPatch{
+		APIVersion: sealedSecret.APIVersion,
+		Kind:       sealedSecret.Kind,
+		Metadata: model.PatchMetadata{
+			Name:        sealedSecret.Metadata.Name,
+			Namespace:   sealedSecret.Metadata.Namespace,
+			Annotations: scopeAnnotations,
+		},
+		Spec: model.SealedSecretPatchSpec{
+			EncryptedData: encryptedData,
+			Template: model.PatchTemplate{
+				Metadata: model.PatchMetadata{
+					Name:        sealedSecret.Metadata.Name,
+					Namespace:   sealedSecret.Metadata.Namespace,
+					Annotations: scopeAnnotations,
+				},
+				Data: sealedSecret.Spec.Template.Data,
+			},
+		},
+	}
+}
+
+// getScopePatchAnnotations returns the scope annotations of the patch. When
+// the scope changes, the annotations of other scopes are removed, otherwise
+// the object would keep both and the controller could not decrypt it.
+func getScopePatchAnnotations(scope, existingScope string) map[string]*string {
+	annotations := make(map[string]*string)
+	for key, value := range getScopeAnnotations(scope) {
+		annotations[key] = &value
+	}
+
+	if existingScope == "" || existingScope == scope {
+		return annotations
+	}
+
+	for _, key := range []string{"sealedsecrets.bitnami.com/cluster-wide", "sealedsecrets.bitnami.com/namespace-wide"} {
+		if _, ok := annotations[key]; !ok {
+			annotations[key] = nil
+		}
+	}
+
+	return annotations
+}
@@ -0,0 +1,237 @@
+package sealedsecret
+
+import (
+	"testing"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSealedSecretFromUnstructured(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "bitnami.com/v1alpha1",
+		"kind":       "SealedSecret",
+		"metadata": map[string]interface{}{
+			"name":      "app",
+			"namespace": "default",
+			"annotations": map[string]interface{}{
+				"sealedsecrets.bitnami.com/namespace-wide": "true",
+			},
+		},
+		"spec": map[string]interface{}{
+			"encryptedData": map[string]interface{}{
+				"API_TOKEN": "AgB...",
+			},
+		},
+	}}
+
+	got, err := sealedSecretFromUnstructured(obj)
+	require.NoError(t, err)
+	assert.Equal(t, "app", got.Metadata.Name)
+	assert.Equal(t, "default", got.Metadata.Namespace)
+	assert.Equal(t, map[string]string{"API_TOKEN": "AgB..."}, got.Spec.EncryptedData)
+	assert.Equal(t, "namespace", getScope(got))
+}
+
+func TestParseSealedSecretRejectsOtherKinds(t *testing.T) {
+	_, err := parseSealedSecret([]byte("apiVersion: v1\nkind: Secret\n"))
+	assert.Error(t, err)
+}
+
+func TestGetScope(t *testing.T) {
+	tcs := []struct {
+		name         string
+		sealedSecret model.SealedSecret
+		want         string
+	}{
+		{
+			name: "cluster",
+			sealedSecret: model.SealedSecret{Metadata: model.Metadata{
+				Annotations: map[string]string{"sealedsecrets.bitnami.com/cluster-wide": "true"},
+			}},
+			want: "cluster",
+		},
+		{
+			name: "namespace from template",
+			sealedSecret: model.SealedSecret{Spec: model.SealedSecretSpec{Template: model.Template{Metadata: model.Metadata{
+				Annotations: map[string]string{"sealedsecrets.bitnami.com/namespace-wide": "true"},
+			}}}},
+			want: "namespace",
+		},
+		{
+			name:         "strict",
+			sealedSecret: model.SealedSecret{},
+			want:         "strict",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, getScope(tc.sealedSecret))
+		})
+	}
+}
+
+func TestRenderManifestPatch(t *testing.T) {
+	sealedSecret := model.SealedSecret{
+		APIVersion: "bitnami.com/v1alpha1",
+		Kind:       "SealedSecret",
+		Metadata: model.Metadata{
+			Name:        "app",
+			Namespace:   "default",
+			Annotations: map[string]string{"sealedsecrets.bitnami.com/namespace-wide": "true", "custom.example/key": "value"},
+		},
+		Spec: model.SealedSecretSpec{
+			EncryptedData: map[string]string{"API_TOKEN": "AgA...", "PG_PASSWORD": "AgB..."},
+		},
+	}
+
+	got, err := renderManifest(sealedSecret, map[string]string{"API_TOKEN": "AgA..."}, []string{"OLD_KEY"}, "patch", "namespace")
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: app
+  namespace: default
+  annotations:
+    sealedsecrets.bitnami.com/namespace-wide: "true"
+spec:
+  encryptedData:
+    API_TOKEN: AgA...
+    OLD_KEY: null
+  template:
+    metadata:
+      name: app
+      namespace: default
+      annotations:
+        sealedsecrets.bitnami.com/namespace-wide: "true"
+`, got)
+}
+
+func TestRenderManifestPatchScopeChange(t *testing.T) {
+	tcs := []struct {
+		name          string
+		scope         string
+		existingScope string
+		want          string
+	}{
+		{
+			name:          "strict to cluster",
+			scope:         "cluster",
+			existingScope: "strict",
+			want: `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: app
+  namespace: default
+  annotations:
+    sealedsecrets.bitnami.com/cluster-wide: "true"
+    sealedsecrets.bitnami.com/namespace-wide: null
+spec:
+  encryptedData:
+    API_TOKEN: AgA...
+  template:
+    metadata:
+      name: app
+      namespace: default
+      annotations:
+        sealedsecrets.bitnami.com/cluster-wide: "true"
+        sealedsecrets.bitnami.com/namespace-wide: null
+`,
+		},
+		{
+			name:          "namespace to cluster",
+			scope:         "cluster",
+			existingScope: "namespace",
+			want: `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: app
+  namespace: default
+  annotations:
+    sealedsecrets.bitnami.com/cluster-wide: "true"
+    sealedsecrets.bitnami.com/namespace-wide: null
+spec:
+  encryptedData:
+    API_TOKEN: AgA...
+  template:
+    metadata:
+      name: app
+      namespace: default
+      annotations:
+        sealedsecrets.bitnami.com/cluster-wide: "true"
+        sealedsecrets.bitnami.com/namespace-wide: null
+`,
+		},
+		{
+			name:          "namespace to strict",
+			scope:         "strict",
+			existingScope: "namespace",
+			want: `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: app
+  namespace: default
+  annotations:
+    sealedsecrets.bitnami.com/cluster-wide: null
+    sealedsecrets.bitnami.com/namespace-wide: null
+spec:
+  encryptedData:
+    API_TOKEN: AgA...
+  template:
+    metadata:
+      name: app
+      namespace: default
+      annotations:
+        sealedsecrets.bitnami.com/cluster-wide: null
+        sealedsecrets.bitnami.com/namespace-wide: null
+`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sealedSecret := model.SealedSecret{
+				APIVersion: "bitnami.com/v1alpha1",
+				Kind:       "SealedSecret",
+				Metadata: model.Metadata{
+					Name:        "app",
+					Namespace:   "default",
+					Annotations: getScopeAnnotations(tc.scope),
+				},
+			}
+
+			got, err := renderManifest(sealedSecret, map[string]string{"API_TOKEN": "AgA..."}, nil, "patch", tc.existingScope)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	tcs := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{
+			name:     "other kind",
+			manifest: "apiVersion: v1\nkind: Secret\n",
+			want:     `unexpected kind "Secret", expected SealedSecret`,
+		},
+		{
+			name:     "without namespace",
+			manifest: "apiVersion: bitnami.com/v1alpha1\nkind: SealedSecret\nmetadata:\n  name: app\n",
+			want:     "the sealed secret manifest must have a name and a namespace",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseManifest(tc.manifest)
+			assert.Equal(t, model.ValidationError{Errors: []model.FieldError{{Field: "manifest", Message: tc.want}}}, err)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package sealedsecret
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"gopkg.in/yaml.v2"
+)
+
+// MergeManifests does a three-way merge of two SealedSecret manifests that
+// were changed from the same base. The result is based on ours, the encrypted
+// data, labels, annotations and template fields are merged. Keys that were
+// changed differently on both sides are reported as conflicts and keep the
+// value of ours.
+func MergeManifests(opts model.MergeOpts) (model.MergeResult, error) {
+	base := model.SealedSecret{}
+	if strings.TrimSpace(opts.Base) != "" {
+		var err error
+		base, err = parseSealedSecret([]byte(opts.Base))
+		if err != nil {
+			return model.MergeResult{}, fmt.Errorf("base: %w", err)
+		}
+	}
+
+	ours, err := parseSealedSecret([]byte(opts.Ours))
+	if err != nil {
+		return model.MergeResult{}, fmt.Errorf("ours: %w", err)
+	}
+
+	theirs, err := parseSealedSecret([]byte(opts.Theirs))
+	if err != nil {
+		return model.MergeResult{}, fmt.Errorf("theirs: %w", err)
+	}
+
+	if ours.Metadata.Name != theirs.Metadata.Name || ours.Metadata.Namespace != theirs.Metadata.Namespace {
+		return model.MergeResult{}, fmt.Errorf("cannot merge %s/%s with %s/%s", ours.Metadata.Namespace, ours.Metadata.Name, theirs.Metadata.Namespace, theirs.Metadata.Name)
+	}
+
+	// the ciphertext is bound to the scope, keys sealed for different scopes
+	// can't live in the same manifest
+	if oursScope, theirsScope := getScope(ours), getScope(theirs); oursScope != theirsScope {
+		return model.MergeResult{}, fmt.Errorf("cannot merge manifests sealed with different scopes (%s and %s)", oursScope, theirsScope)
+	}
+
+	conflicts := []model.MergeConflict{}
+	merged := ours
+
+	var fieldConflicts []string
+	merged.Spec.EncryptedData, fieldConflicts = mergeStringMaps(base.Spec.EncryptedData, ours.Spec.EncryptedData, theirs.Spec.EncryptedData)
+	conflicts = appendConflicts(conflicts, "encryptedData", fieldConflicts)
+
+	merged.Metadata.Annotations, fieldConflicts = mergeStringMaps(base.Metadata.Annotations, ours.Metadata.Annotations, theirs.Metadata.Annotations)
+	conflicts = appendConflicts(conflicts, "metadata.annotations", fieldConflicts)
+
+	merged.Metadata.Labels, fieldConflicts = mergeStringMaps(base.Metadata.Labels, ours.Metadata.Labels, theirs.Metadata.Labels)
+	conflicts = appendConflicts(conflicts, "metadata.labels", fieldConflicts)
+
+	baseTemplate, oursTemplate, theirsTemplate := base.Spec.Template, ours.Spec.Template, theirs.Spec.Template
+	merged.Spec.Template.Metadata.Annotations, fieldConflicts = mergeStringMaps(baseTemplate.Metadata.Annotations, oursTemplate.Metadata.Annotations, theirsTemplate.Metadata.Annotations)
+	conflicts = appendConflicts(conflicts, "spec.template.metadata.annotations", fieldConflicts)
+
+	merged.Spec.Template.Metadata.Labels, fieldConflicts = mergeStringMaps(baseTemplate.Metadata.Labels, oursTemplate.Metadata.Labels, theirsTemplate.Metadata.Labels)
+	conflicts = appendConflicts(conflicts, "spec.template.metadata.labels", fieldConflicts)
+
+	merged.Spec.Template.Data, fieldConflicts = mergeStringMaps(baseTemplate.Data, oursTemplate.Data, theirsTemplate.Data)
+	conflicts = appendConflicts(conflicts, "spec.template.data", fieldConflicts)
+
+	var conflict bool
+	merged.Spec.Template.Type, conflict = mergeValue(baseTemplate.Type, oursTemplate.Type, theirsTemplate.Type)
+	if conflict {
+		conflicts = append(conflicts, model.MergeConflict{Field: "spec.template", Key: "type"})
+	}
+
+	merged.Spec.Template.Immutable, conflict = mergeValue(baseTemplate.Immutable, oursTemplate.Immutable, theirsTemplate.Immutable)
+	if conflict {
+		conflicts = append(conflicts, model.MergeConflict{Field: "spec.template", Key: "immutable"})
+	}
+
+	yamlData, err := yaml.Marshal(merged)
+	if err != nil {
+		return model.MergeResult{}, fmt.Errorf("failed to marshal sealed secret to YAML: %w", err)
+	}
+
+	return model.MergeResult{
+		Manifest:  string(yamlData),
+		Conflicts: conflicts,
+	}, nil
+}
+
+// mergeStringMaps merges the changes of ours and theirs into base. It returns
+// the sorted keys that were changed differently on both sides.
+func mergeStringMaps(base, ours, theirs map[string]string) (map[string]string, []string) {
+	keys := make(map[string]struct{}, len(ours)+len(theirs))
+	for _, source := range []map[string]string{base, ours, theirs} {
+		for key := range source {
+			keys[key] = struct{}{}
+		}
+	}
+
+	results := make(map[string]string, len(keys))
+	conflicts := []string{}
+	for key := range keys {
+		baseValue, inBase := base[key]
+		oursValue, inOurs := ours[key]
+		theirsValue, inTheirs := theirs[key]
+
+		switch {
+		case inOurs == inTheirs && oursValue == theirsValue:
+			// both sides agree
+		case inBase == inOurs && baseValue == oursValue:
+			// only theirs changed the key
+			oursValue, inOurs = theirsValue, inTheirs
+		case inBase == inTheirs && baseValue == theirsValue:
+			// only ours changed the key
+		default:
+			conflicts = append(conflicts, key)
+			if !inOurs {
+				oursValue, inOurs = theirsValue, inTheirs
+			}
+		}
+
+		if inOurs {
+			results[key] = oursValue
+		}
+	}
+
+	sort.Strings(conflicts)
+	return results, conflicts
+}
+
+// mergeValue merges a single field the way mergeStringMaps merges a key. It
+// keeps ours and reports a conflict when both sides changed it differently.
+func mergeValue[T comparable](base, ours, theirs T) (T, bool) {
+	switch {
+	case ours == theirs, base == theirs:
+		return ours, false
+	case base == ours:
+		return theirs, false
+	default:
+		return ours, true
+	}
+}
+
+func appendConflicts(conflicts []model.MergeConflict, field string, keys []string) []model.MergeConflict {
+	for _, key := range keys {
+		conflicts = append(conflicts, model.MergeConflict{Field: field, Key: key})
+	}
+
+	return conflicts
+}
@@ -0,0 +1,216 @@
+package sealedsecret
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeStringMaps(t *testing.T) {
+	base := map[string]string{
+		"UNCHANGED": "AgA...",
+		"OURS":      "AgB...",
+		"THEIRS":    "AgC...",
+		"BOTH":      "AgD...",
+		"REMOVED":   "AgE...",
+	}
+	ours := map[string]string{
+		"UNCHANGED": "AgA...",
+		"OURS":      "AgB-ours...",
+		"THEIRS":    "AgC...",
+		"BOTH":      "AgD-ours...",
+		"ADDED":     "AgF-ours...",
+	}
+	theirs := map[string]string{
+		"UNCHANGED": "AgA...",
+		"OURS":      "AgB...",
+		"THEIRS":    "AgC-theirs...",
+		"BOTH":      "AgD-theirs...",
+		"REMOVED":   "AgE...",
+		"ADDED":     "AgF-theirs...",
+	}
+
+	got, gotConflicts := mergeStringMaps(base, ours, theirs)
+
+	assert.Equal(t, map[string]string{
+		"UNCHANGED": "AgA...",
+		"OURS":      "AgB-ours...",
+		"THEIRS":    "AgC-theirs...",
+		"BOTH":      "AgD-ours...",
+		"ADDED":     "AgF-ours...",
+	}, got)
+	assert.Equal(t, []string{"ADDED", "BOTH"}, gotConflicts)
+}
+
+func TestMergeManifests(t *testing.T) {
+	base := `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: app
+  namespace: default
+spec:
+  encryptedData:
+    API_TOKEN: AgA...
+`
+	ours := `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: app
+  namespace: default
+spec:
+  encryptedData:
+    API_TOKEN: AgA...
+    OURS_KEY: AgB...
+`
+	theirs := `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: app
+  namespace: default
+spec:
+  encryptedData:
+    API_TOKEN: AgA...
+    THEIRS_KEY: AgC...
+`
+
+	got, err := MergeManifests(model.MergeOpts{Base: base, Ours: ours, Theirs: theirs})
+	require.NoError(t, err)
+	assert.Empty(t, got.Conflicts)
+
+	merged, err := parseSealedSecret([]byte(got.Manifest))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"API_TOKEN":  "AgA...",
+		"OURS_KEY":   "AgB...",
+		"THEIRS_KEY": "AgC...",
+	}, merged.Spec.EncryptedData)
+}
+
+func TestMergeManifestsRejectsDifferentScopes(t *testing.T) {
+	ours := `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: app
+  namespace: default
+`
+	theirs := `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: app
+  namespace: default
+  annotations:
+    sealedsecrets.bitnami.com/cluster-wide: "true"
+`
+
+	_, err := MergeManifests(model.MergeOpts{Ours: ours, Theirs: theirs})
+	assert.Error(t, err)
+}
+
+func TestMergeManifestsTakesTheirChanges(t *testing.T) {
+	base := `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: app
+  namespace: default
+  labels:
+    team: payments
+spec:
+  encryptedData:
+    API_TOKEN: AgA...
+  template:
+    metadata:
+      labels:
+        app: web
+    type: kubernetes.io/basic-auth
+    data:
+      url: https://{{ .username }}@example.com
+`
+
+	tcs := []struct {
+		name   string
+		from   string
+		to     string
+		assert func(t *testing.T, merged model.SealedSecret)
+	}{
+		{
+			name: "sealed secret labels",
+			from: "    team: payments\nspec:",
+			to:   "    team: checkout\nspec:",
+			assert: func(t *testing.T, merged model.SealedSecret) {
+				assert.Equal(t, map[string]string{"team": "checkout"}, merged.Metadata.Labels)
+			},
+		},
+		{
+			name: "template labels",
+			from: "        app: web",
+			to:   "        app: api",
+			assert: func(t *testing.T, merged model.SealedSecret) {
+				assert.Equal(t, map[string]string{"app": "api"}, merged.Spec.Template.Metadata.Labels)
+			},
+		},
+		{
+			name: "template type",
+			from: "    type: kubernetes.io/basic-auth",
+			to:   "    type: Opaque",
+			assert: func(t *testing.T, merged model.SealedSecret) {
+				assert.Equal(t, "Opaque", merged.Spec.Template.Type)
+			},
+		},
+		{
+			name: "template data",
+			from: "      url: https://{{ .username }}@example.com",
+			to:   "      url: https://{{ .username }}@example.org",
+			assert: func(t *testing.T, merged model.SealedSecret) {
+				assert.Equal(t, map[string]string{"url": "https://{{ .username }}@example.org"}, merged.Spec.Template.Data)
+			},
+		},
+		{
+			name: "immutable",
+			from: "    type: kubernetes.io/basic-auth",
+			to:   "    type: kubernetes.io/basic-auth\n    immutable: true",
+			assert: func(t *testing.T, merged model.SealedSecret) {
+				assert.True(t, merged.Spec.Template.Immutable)
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			theirs := strings.Replace(base, tc.from, tc.to, 1)
+			require.NotEqual(t, base, theirs)
+
+			got, err := MergeManifests(model.MergeOpts{Base: base, Ours: base, Theirs: theirs})
+			require.NoError(t, err)
+			assert.Empty(t, got.Conflicts)
+
+			merged, err := parseSealedSecret([]byte(got.Manifest))
+			require.NoError(t, err)
+			tc.assert(t, merged)
+		})
+	}
+}
+
+func TestMergeManifestsReportsTemplateConflicts(t *testing.T) {
+	manifest := `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: app
+  namespace: default
+spec:
+  template:
+    type: %s
+`
+
+	got, err := MergeManifests(model.MergeOpts{
+		Base:   fmt.Sprintf(manifest, "Opaque"),
+		Ours:   fmt.Sprintf(manifest, "kubernetes.io/tls"),
+		Theirs: fmt.Sprintf(manifest, "kubernetes.io/basic-auth"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []model.MergeConflict{{Field: "spec.template", Key: "type"}}, got.Conflicts)
+	assert.Contains(t, got.Manifest, "type: kubernetes.io/tls")
+}
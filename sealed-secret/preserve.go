@@ -0,0 +1,167 @@
+package sealedsecret
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// PreserveRules select the annotation and label keys that are copied from an
+// existing SealedSecret to the new manifest. A rule is either an exact key, a
+// glob pattern such as "argocd.argoproj.io/*", or a prefix ending with "/".
+// Rules starting with "!" exclude the keys they match and win over the other
+// rules of the same set.
+type PreserveRules struct {
+	Annotations []string `yaml:"annotations"`
+	Labels      []string `yaml:"labels"`
+}
+
+// PreserveConfig holds the global rules and the rules that are added for
+// specific namespaces. The rules of a namespace take precedence: a key they
+// keep or exclude is not looked up in the global rules, so a namespace can
+// keep a key that is excluded globally and the other way around.
+type PreserveConfig struct {
+	PreserveRules `yaml:",inline"`
+	Namespaces    map[string]PreserveRules `yaml:"namespaces"`
+}
+
+// LoadPreserveConfig reads the preserve config from a YAML file. An empty
+// path returns an empty config.
+func LoadPreserveConfig(configPath string) (PreserveConfig, error) {
+	if configPath == "" {
+		return PreserveConfig{}, nil
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return PreserveConfig{}, fmt.Errorf("failed to read preserve config: %w", err)
+	}
+
+	var config PreserveConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return PreserveConfig{}, fmt.Errorf("failed to parse preserve config: %w", err)
+	}
+
+	return config, nil
+}
+
+// validate reports malformed glob patterns.
+func (c PreserveConfig) validate() error {
+	ruleSets := []PreserveRules{c.PreserveRules}
+	for _, namespaceRules := range c.Namespaces {
+		ruleSets = append(ruleSets, namespaceRules)
+	}
+
+	for _, rules := range ruleSets {
+		for _, rule := range append(append([]string{}, rules.Annotations...), rules.Labels...) {
+			rule = strings.TrimPrefix(strings.TrimSpace(rule), "!")
+			if _, err := path.Match(rule, ""); err != nil {
+				return fmt.Errorf("invalid preserve rule %q: %w", rule, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// rulesFor returns the global rules followed by the rules of the namespace,
+// in the order they are shown.
+func (c PreserveConfig) rulesFor(namespace string) PreserveRules {
+	namespaceRules := c.Namespaces[namespace]
+
+	return PreserveRules{
+		Annotations: append(append([]string{}, c.Annotations...), namespaceRules.Annotations...),
+		Labels:      append(append([]string{}, c.Labels...), namespaceRules.Labels...),
+	}
+}
+
+// matchersFor returns the matchers of the annotations and labels of the
+// namespace, which fall back to the global rules.
+func (c PreserveConfig) matchersFor(namespace string) (keyMatcher, keyMatcher) {
+	namespaceRules := c.Namespaces[namespace]
+	annotations, labels := newKeyMatcher(namespaceRules.Annotations), newKeyMatcher(namespaceRules.Labels)
+	globalAnnotations, globalLabels := newKeyMatcher(c.Annotations), newKeyMatcher(c.Labels)
+	annotations.fallback, labels.fallback = &globalAnnotations, &globalLabels
+
+	return annotations, labels
+}
+
+type keyMatcher struct {
+	exact    map[string]struct{}
+	patterns []string
+	excludes []string
+	// fallback decides about the keys none of the rules match
+	fallback *keyMatcher
+}
+
+func newKeyMatcher(rules []string) keyMatcher {
+	exact := []string{}
+	matcher := keyMatcher{}
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		switch {
+		case rule == "":
+			continue
+		case strings.HasPrefix(rule, "!"):
+			matcher.excludes = append(matcher.excludes, strings.TrimSpace(rule[1:]))
+		case isKeyPattern(rule):
+			matcher.patterns = append(matcher.patterns, rule)
+		default:
+			exact = append(exact, rule)
+		}
+	}
+	matcher.exact = toStringSet(exact)
+
+	return matcher
+}
+
+func (m keyMatcher) matches(key string) bool {
+	for _, rule := range m.excludes {
+		if matchRule(rule, key) {
+			return false
+		}
+	}
+
+	if _, ok := m.exact[key]; ok {
+		return true
+	}
+
+	for _, rule := range m.patterns {
+		if matchRule(rule, key) {
+			return true
+		}
+	}
+
+	if m.fallback != nil {
+		return m.fallback.matches(key)
+	}
+
+	return false
+}
+
+func (m keyMatcher) filter(values map[string]string) map[string]string {
+	results := make(map[string]string)
+	for key, value := range values {
+		if m.matches(key) {
+			results[key] = value
+		}
+	}
+
+	return results
+}
+
+func isKeyPattern(rule string) bool {
+	return strings.HasSuffix(rule, "/") || strings.ContainsAny(rule, "*?[")
+}
+
+func matchRule(rule, key string) bool {
+	if strings.HasSuffix(rule, "/") {
+		return strings.HasPrefix(key, rule)
+	}
+
+	matched, err := path.Match(rule, key)
+	return err == nil && matched
+}
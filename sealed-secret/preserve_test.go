@@ -0,0 +1,88 @@
+package sealedsecret
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyMatcher(t *testing.T) {
+	matcher := newKeyMatcher([]string{
+		" custom.example/key ",
+		"argocd.argoproj.io/*",
+		"kustomize.toolkit.fluxcd.io/",
+		"!argocd.argoproj.io/tracking-id",
+		"",
+	})
+
+	tcs := []struct {
+		key  string
+		want bool
+	}{
+		{key: "custom.example/key", want: true},
+		{key: "custom.example/other", want: false},
+		{key: "argocd.argoproj.io/sync-wave", want: true},
+		{key: "argocd.argoproj.io/tracking-id", want: false},
+		{key: "kustomize.toolkit.fluxcd.io/reconcile", want: true},
+		{key: "team.example.com/owner", want: false},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			assert.Equal(t, tc.want, matcher.matches(tc.key))
+		})
+	}
+}
+
+func TestPreserveConfigRulesFor(t *testing.T) {
+	config := PreserveConfig{
+		PreserveRules: PreserveRules{
+			Annotations: []string{"argocd.argoproj.io/*"},
+			Labels:      []string{"app.kubernetes.io/*"},
+		},
+		Namespaces: map[string]PreserveRules{
+			"team-a": {Annotations: []string{"team-a.example.com/*", "!argocd.argoproj.io/sync-wave"}},
+		},
+	}
+
+	assert.Equal(t, PreserveRules{
+		Annotations: []string{"argocd.argoproj.io/*", "team-a.example.com/*", "!argocd.argoproj.io/sync-wave"},
+		Labels:      []string{"app.kubernetes.io/*"},
+	}, config.rulesFor("team-a"))
+	assert.Equal(t, config.PreserveRules, config.rulesFor("team-b"))
+}
+
+func TestPreserveConfigValidate(t *testing.T) {
+	assert.NoError(t, PreserveConfig{PreserveRules: PreserveRules{Annotations: []string{"argocd.argoproj.io/*"}}}.validate())
+	assert.Error(t, PreserveConfig{PreserveRules: PreserveRules{Labels: []string{"team[/*"}}}.validate())
+}
+
+func TestPreserveConfigNamespaceRulesTakePrecedence(t *testing.T) {
+	config := PreserveConfig{
+		PreserveRules: PreserveRules{
+			Annotations: []string{"argocd.argoproj.io/*", "!argocd.argoproj.io/tracking-id"},
+		},
+		Namespaces: map[string]PreserveRules{
+			"team-a": {Annotations: []string{"argocd.argoproj.io/tracking-id", "!argocd.argoproj.io/sync-wave"}},
+		},
+	}
+
+	tcs := []struct {
+		namespace string
+		key       string
+		want      bool
+	}{
+		{namespace: "team-a", key: "argocd.argoproj.io/tracking-id", want: true},
+		{namespace: "team-a", key: "argocd.argoproj.io/sync-wave", want: false},
+		{namespace: "team-a", key: "argocd.argoproj.io/compare-options", want: true},
+		{namespace: "team-b", key: "argocd.argoproj.io/tracking-id", want: false},
+		{namespace: "team-b", key: "argocd.argoproj.io/sync-wave", want: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.namespace+"/"+tc.key, func(t *testing.T) {
+			annotations, _ := config.matchersFor(tc.namespace)
+			assert.Equal(t, tc.want, annotations.matches(tc.key))
+		})
+	}
+}
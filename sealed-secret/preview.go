@@ -0,0 +1,120 @@
+package sealedsecret
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+)
+
+// PreviewSealedSecret classifies the keys the submitted values would add,
+// change or remove, along with the metadata and scope changes. It runs the
+// checks of sealing, but seals nothing. Values are only compared by their
+// hashes and never returned.
+func (s SealedSecretService) PreviewSealedSecret(ctx context.Context, opts model.CreateOpts) (model.PreviewResult, error) {
+	// the generated values are discarded, they only show up as new keys
+	if err := previewValues(&opts); err != nil {
+		return model.PreviewResult{}, err
+	}
+
+	var prepared sealing
+	var err error
+	mode := opts.Mode
+	if opts.Manifest != "" {
+		prepared, err = s.prepareExtension(opts, true)
+		// the values of a pasted manifest cannot be read, so submitted keys it
+		// already has are overwritten
+		if mode != "replace" {
+			mode = "merge-sealed"
+		}
+	} else {
+		prepared, err = s.prepareSealing(ctx, opts, true)
+	}
+	if err != nil {
+		return model.PreviewResult{}, err
+	}
+
+	var sealedKeys map[string]string
+	if prepared.existing != nil {
+		sealedKeys = prepared.existing.Spec.EncryptedData
+	}
+
+	return model.PreviewResult{
+		Keys:            classifyKeys(sealedKeys, prepared.existingData, opts.Values, mode),
+		MetadataChanges: getMetadataChanges(prepared.existing, prepared.sealedSecret),
+		ScopeChange:     prepared.scopeChange,
+		LintIssues:      lintValues(submittedValues(opts), s.lintErrors),
+	}, nil
+}
+
+// classifyKeys mirrors how the mode combines the existing keys with the
+// submitted values. sealedKeys are the keys of the existing SealedSecret,
+// existingData the values of the existing Secret, if it was read.
+func classifyKeys(sealedKeys map[string]string, existingData, values map[string][]byte, mode string) []model.KeyChange {
+	existingKeys := make(map[string]struct{}, len(sealedKeys)+len(existingData))
+	for key := range sealedKeys {
+		existingKeys[key] = struct{}{}
+	}
+	for key := range existingData {
+		existingKeys[key] = struct{}{}
+	}
+
+	resultingKeys := make(map[string]struct{}, len(existingKeys)+len(values))
+	switch mode {
+	case "replace":
+	case "merge-sealed":
+		for key := range sealedKeys {
+			resultingKeys[key] = struct{}{}
+		}
+	default:
+		for key := range existingData {
+			resultingKeys[key] = struct{}{}
+		}
+	}
+	for key := range values {
+		resultingKeys[key] = struct{}{}
+	}
+
+	allKeys := make(map[string]struct{}, len(existingKeys)+len(values))
+	for key := range existingKeys {
+		allKeys[key] = struct{}{}
+	}
+	for key := range resultingKeys {
+		allKeys[key] = struct{}{}
+	}
+
+	results := make([]model.KeyChange, 0, len(allKeys))
+	for _, key := range sortedKeys(allKeys) {
+		_, existed := existingKeys[key]
+		_, remains := resultingKeys[key]
+		value, submitted := values[key]
+		existingValue, compared := existingData[key]
+
+		status := "unchanged"
+		switch {
+		case !remains:
+			status = "removed"
+		case !existed:
+			status = "added"
+		case !submitted:
+		case !compared:
+			status = "overwritten"
+		case !sameValue(existingValue, value):
+			status = "changed"
+		}
+
+		results = append(results, model.KeyChange{Name: key, Status: status})
+	}
+
+	return results
+}
+
+// sameValue compares the hashes of both values in constant time, so the
+// comparison does not leak the existing value.
+func sameValue(a, b []byte) bool {
+	hashA := sha256.Sum256(a)
+	hashB := sha256.Sum256(b)
+
+	return subtle.ConstantTimeCompare(hashA[:], hashB[:]) == 1
+}
@@ -0,0 +1,149 @@
+package sealedsecret
+
+import (
+	"context"
+	"testing"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyKeys(t *testing.T) {
+	sealedKeys := map[string]string{"a": "enc-a", "b": "enc-b", "sealed-only": "enc"}
+	existingData := map[string][]byte{"a": []byte("1"), "b": []byte("2"), "secret-only": []byte("3")}
+	values := map[string][]byte{"a": []byte("1"), "b": []byte("changed"), "new": []byte("4")}
+
+	tcs := []struct {
+		name         string
+		existingData map[string][]byte
+		mode         string
+		want         []model.KeyChange
+	}{
+		{
+			name:         "merge",
+			existingData: existingData,
+			mode:         "merge",
+			want: []model.KeyChange{
+				{Name: "a", Status: "unchanged"},
+				{Name: "b", Status: "changed"},
+				{Name: "new", Status: "added"},
+				{Name: "sealed-only", Status: "removed"},
+				{Name: "secret-only", Status: "unchanged"},
+			},
+		},
+		{
+			name:         "replace",
+			existingData: existingData,
+			mode:         "replace",
+			want: []model.KeyChange{
+				{Name: "a", Status: "unchanged"},
+				{Name: "b", Status: "changed"},
+				{Name: "new", Status: "added"},
+				{Name: "sealed-only", Status: "removed"},
+				{Name: "secret-only", Status: "removed"},
+			},
+		},
+		{
+			name: "merge sealed",
+			mode: "merge-sealed",
+			want: []model.KeyChange{
+				{Name: "a", Status: "overwritten"},
+				{Name: "b", Status: "overwritten"},
+				{Name: "new", Status: "added"},
+				{Name: "sealed-only", Status: "unchanged"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := classifyKeys(sealedKeys, tc.existingData, values, tc.mode)
+
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestSameValue(t *testing.T) {
+	assert.True(t, sameValue([]byte("secret"), []byte("secret")))
+	assert.False(t, sameValue([]byte("secret"), []byte("secret\n")))
+	assert.True(t, sameValue(nil, []byte{}))
+}
+
+func TestPreviewSealedSecretRunsSealingChecks(t *testing.T) {
+	manifest := `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: app
+  namespace: default
+spec:
+  encryptedData:
+    username: AgB...
+`
+
+	tcs := []struct {
+		name      string
+		opts      model.CreateOpts
+		wantField string
+		want      model.PreviewResult
+	}{
+		{
+			name: "secret type without the required keys",
+			opts: model.CreateOpts{
+				Values: map[string][]byte{"API_TOKEN": []byte("s3cr3t-t0k3n")},
+				Type:   "kubernetes.io/tls",
+			},
+			wantField: "type",
+		},
+		{
+			name: "template with unknown key",
+			opts: model.CreateOpts{
+				Values:       map[string][]byte{"password": []byte("s3cr3t-p4ssw0rd")},
+				TemplateData: map[string]string{"url": "https://{{ .user }}@example.com"},
+			},
+			wantField: "templateData",
+		},
+		{
+			name: "patching an immutable secret",
+			opts: model.CreateOpts{
+				Values:    map[string][]byte{"password": []byte("s3cr3t-p4ssw0rd")},
+				Immutable: true,
+				Patch:     true,
+			},
+			wantField: "behaviour",
+		},
+		{
+			name: "generated certificate",
+			opts: model.CreateOpts{
+				Generators: []model.Generator{{Kind: "tls", Subject: "app.example.com"}},
+			},
+			want: model.PreviewResult{
+				Keys: []model.KeyChange{
+					{Name: "ca.crt", Status: "added"},
+					{Name: "tls.crt", Status: "added"},
+					{Name: "tls.key", Status: "added"},
+					{Name: "username", Status: "unchanged"},
+				},
+				MetadataChanges: []model.MetadataChange{},
+				LintIssues:      []model.LintIssue{},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.Manifest = manifest
+			got, err := SealedSecretService{}.PreviewSealedSecret(context.Background(), tc.opts)
+			if tc.wantField != "" {
+				var validationErr model.ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, tc.wantField, validationErr.Errors[0].Field)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
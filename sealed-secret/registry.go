@@ -0,0 +1,163 @@
+package sealedsecret
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// dockerAuth is an entry of the auths of a .dockerconfigjson.
+type dockerAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email,omitempty"`
+	Auth     string `json:"auth"`
+}
+
+// BuildRegistrySecret seals an image pull secret with the credentials of the
+// given registries. The other registries of the existing Secret are kept.
+func (s SealedSecretService) BuildRegistrySecret(ctx context.Context, opts model.RegistryOpts) (model.CreateResult, error) {
+	if err := validateRegistries(opts.Registries); err != nil {
+		return model.CreateResult{}, err
+	}
+
+	existingData, err := s.getSecretData(ctx, opts.Namespace, opts.SecretName)
+	if err != nil {
+		return model.CreateResult{}, fmt.Errorf("failed to get existing secret data: %w", err)
+	}
+
+	config, keptRegistries, err := buildDockerConfig(existingData[corev1.DockerConfigJsonKey], opts.Registries)
+	if err != nil {
+		return model.CreateResult{}, err
+	}
+
+	result, err := s.CreateSealedSecret(ctx, model.CreateOpts{
+		Scope:              opts.Scope,
+		Mode:               "merge",
+		Namespace:          opts.Namespace,
+		SecretName:         opts.SecretName,
+		Values:             map[string][]byte{corev1.DockerConfigJsonKey: config},
+		Type:               string(corev1.SecretTypeDockerConfigJson),
+		ConfirmScopeChange: opts.ConfirmScopeChange,
+	})
+	if err != nil {
+		return model.CreateResult{}, err
+	}
+
+	result.KeptRegistries = keptRegistries
+	return result, nil
+}
+
+// validateRegistries checks the credentials, the auth field joins the username
+// and the password with a colon, so the username cannot contain one.
+func validateRegistries(registries []model.RegistryCredential) error {
+	errs := []model.FieldError{}
+	if len(registries) == 0 {
+		errs = append(errs, model.FieldError{Field: "registries", Message: "at least one registry is required"})
+	}
+
+	servers := make(map[string]struct{}, len(registries))
+	for i, registry := range registries {
+		add := func(message string) {
+			errs = append(errs, model.FieldError{Field: "registries", Message: fmt.Sprintf("registry %d: %s", i+1, message)})
+		}
+
+		switch {
+		case registry.Server == "":
+			add("server is required")
+		case strings.ContainsAny(registry.Server, " \t\r\n"):
+			add("server must not contain whitespace")
+		}
+
+		if _, ok := servers[registry.Server]; ok && registry.Server != "" {
+			add(fmt.Sprintf("duplicate server %q", registry.Server))
+		}
+		servers[registry.Server] = struct{}{}
+
+		switch {
+		case registry.Username == "":
+			add("username is required")
+		case strings.Contains(registry.Username, ":"):
+			add("username must not contain ':'")
+		}
+
+		if registry.Password == "" {
+			add("password is required")
+		}
+	}
+
+	if len(errs) > 0 {
+		return model.ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+// buildDockerConfig adds the registries to the existing .dockerconfigjson,
+// replacing the entries of the same servers. Other fields of the existing
+// config are kept as they are. It returns the kept registries.
+func buildDockerConfig(existing []byte, registries []model.RegistryCredential) ([]byte, []string, error) {
+	config := make(map[string]json.RawMessage)
+	auths := make(map[string]json.RawMessage)
+	if len(existing) > 0 {
+		if err := json.Unmarshal(existing, &config); err != nil {
+			return nil, nil, fmt.Errorf("existing %s is not valid JSON: %w", corev1.DockerConfigJsonKey, err)
+		}
+
+		if raw, ok := config["auths"]; ok {
+			if err := json.Unmarshal(raw, &auths); err != nil {
+				return nil, nil, fmt.Errorf("existing %s has invalid auths: %w", corev1.DockerConfigJsonKey, err)
+			}
+		}
+	}
+
+	for _, registry := range registries {
+		auth, err := json.Marshal(dockerAuth{
+			Username: registry.Username,
+			Password: registry.Password,
+			Email:    registry.Email,
+			Auth:     base64.StdEncoding.EncodeToString([]byte(registry.Username + ":" + registry.Password)),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		auths[registry.Server] = auth
+	}
+
+	keptRegistries := []string{}
+	for server := range auths {
+		if !containsRegistry(registries, server) {
+			keptRegistries = append(keptRegistries, server)
+		}
+	}
+	sort.Strings(keptRegistries)
+
+	rawAuths, err := json.Marshal(auths)
+	if err != nil {
+		return nil, nil, err
+	}
+	config["auths"] = rawAuths
+
+	result, err := json.Marshal(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result, keptRegistries, nil
+}
+
+func containsRegistry(registries []model.RegistryCredential, server string) bool {
+	for _, registry := range registries {
+		if registry.Server == server {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,67 @@
+package sealedsecret
+
+import (
+	"testing"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDockerConfig(t *testing.T) {
+	tcs := []struct {
+		name       string
+		existing   string
+		registries []model.RegistryCredential
+		want       string
+		wantKept   []string
+	}{
+		{
+			name:       "new secret",
+			registries: []model.RegistryCredential{{Server: "ghcr.io", Username: "bot", Password: "s3cret", Email: "bot@example.com"}},
+			want:       `{"auths":{"ghcr.io":{"username":"bot","password":"s3cret","email":"bot@example.com","auth":"Ym90OnMzY3JldA=="}}}`,
+			wantKept:   []string{},
+		},
+		{
+			name:       "merges with existing registries",
+			existing:   `{"auths":{"ghcr.io":{"auth":"b2xkOm9sZA=="},"quay.io":{"auth":"cXVheTpxdWF5","identitytoken":"token"}},"credsStore":"desktop"}`,
+			registries: []model.RegistryCredential{{Server: "ghcr.io", Username: "bot", Password: "s3cret"}},
+			want:       `{"auths":{"ghcr.io":{"username":"bot","password":"s3cret","auth":"Ym90OnMzY3JldA=="},"quay.io":{"auth":"cXVheTpxdWF5","identitytoken":"token"}},"credsStore":"desktop"}`,
+			wantKept:   []string{"quay.io"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, kept, err := buildDockerConfig([]byte(tc.existing), tc.registries)
+			require.NoError(t, err)
+
+			assert.JSONEq(t, tc.want, string(got))
+			assert.Equal(t, tc.wantKept, kept)
+		})
+	}
+}
+
+func TestBuildDockerConfigRejectsInvalidExistingConfig(t *testing.T) {
+	_, _, err := buildDockerConfig([]byte(`{"auths":[]}`), []model.RegistryCredential{{Server: "ghcr.io", Username: "bot", Password: "s3cret"}})
+	require.ErrorContains(t, err, "existing .dockerconfigjson has invalid auths")
+}
+
+func TestValidateRegistries(t *testing.T) {
+	err := validateRegistries([]model.RegistryCredential{
+		{Server: "ghcr.io", Username: "bot", Password: "s3cret"},
+		{Server: "ghcr.io", Username: "bot:admin"},
+		{Username: "bot", Password: "s3cret"},
+	})
+
+	var validationErr model.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []model.FieldError{
+		{Field: "registries", Message: `registry 2: duplicate server "ghcr.io"`},
+		{Field: "registries", Message: "registry 2: username must not contain ':'"},
+		{Field: "registries", Message: "registry 2: password is required"},
+		{Field: "registries", Message: "registry 3: server is required"},
+	}, validationErr.Errors)
+
+	require.EqualError(t, validateRegistries(nil), "invalid fields: registries: at least one registry is required")
+}
@@ -3,11 +3,15 @@ package sealedsecret
 import (
 	"context"
 	"crypto/rsa"
+	"crypto/subtle"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/atom363/sealed-secrets-ui/model"
-	"gopkg.in/yaml.v2"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
@@ -18,7 +22,8 @@ type SealedSecretService struct {
 	clusterDomain                   string
 	k8sClient                       *kubernetes.Clientset
 	dynamicClient                   dynamic.Interface
-	annotationsToPreserve           map[string]struct{}
+	preserveConfig                  PreserveConfig
+	lintErrors                      map[string]struct{}
 }
 
 type encryptRequest struct {
@@ -26,10 +31,18 @@ type encryptRequest struct {
 	secretName string
 	namespace  string
 	scope      string
-	values     map[string]string
+	values     map[string][]byte
 }
 
-func NewSealedSecretService(controllerNamespace, controllerName, clusterDomain string, annotationAllowlist []string) (SealedSecretService, error) {
+func NewSealedSecretService(controllerNamespace, controllerName, clusterDomain string, preserveConfig PreserveConfig, lintErrors []string) (SealedSecretService, error) {
+	if err := preserveConfig.validate(); err != nil {
+		return SealedSecretService{}, err
+	}
+
+	if err := validateLintRules(lintErrors); err != nil {
+		return SealedSecretService{}, err
+	}
+
 	config, err := getClusterConfig()
 	if err != nil {
 		config, err = getLocalConfig()
@@ -54,87 +67,471 @@ func NewSealedSecretService(controllerNamespace, controllerName, clusterDomain s
 		clusterDomain:                   clusterDomain,
 		k8sClient:                       clientset,
 		dynamicClient:                   dynamicClient,
-		annotationsToPreserve:           toStringSet(annotationAllowlist),
+		preserveConfig:                  preserveConfig,
+		lintErrors:                      toStringSet(lintErrors),
 	}, nil
 }
 
-func (s SealedSecretService) CreateSealedSecret(ctx context.Context, opts model.CreateOpts) (string, error) {
-	existingData, err := s.getSecretData(ctx, opts.Namespace, opts.SecretName)
+func (s SealedSecretService) CreateSealedSecret(ctx context.Context, opts model.CreateOpts) (model.CreateResult, error) {
+	// generated values go straight into encryption, only the ones asked for
+	// are returned
+	revealedValues, publicValues, err := generateValues(&opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to get existing secret data: %w", err)
+		return model.CreateResult{}, err
 	}
 
-	// we need to encrypt all the existing data as well as the new data
-	valuesToEncrypt := make(map[string]string)
-	for key, value := range existingData {
-		valuesToEncrypt[key] = value
+	if opts.Manifest != "" {
+		result, err := s.extendSealedSecret(ctx, opts)
+		if err != nil {
+			return model.CreateResult{}, err
+		}
+
+		result.RevealedValues = revealedValues
+		result.PublicValues = publicValues
+		return result, nil
 	}
 
-	for key, value := range opts.Values {
-		valuesToEncrypt[key] = value
+	prepared, err := s.prepareSealing(ctx, opts, false)
+	if err != nil {
+		return model.CreateResult{}, err
 	}
 
 	// we need to get the public key every time we create a sealed secret because the
 	// sealed-secrets controller rotates the public key every X hours
 	pubKey, err := s.getPublicKey(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to get public key: %w", err)
+		return model.CreateResult{}, fmt.Errorf("failed to get public key: %w", err)
 	}
 
 	req := encryptRequest{
 		pubKey:     pubKey,
 		secretName: opts.SecretName,
 		namespace:  opts.Namespace,
-		values:     valuesToEncrypt,
+		values:     prepared.valuesToEncrypt,
 		scope:      opts.Scope,
 	}
 
-	encryptedData, err := s.encryptValues(req)
+	newEncryptedData, err := s.encryptValues(req)
 	if err != nil {
-		return "", err
+		return model.CreateResult{}, err
+	}
+
+	encryptedData := copyStringMap(newEncryptedData)
+	for key, value := range prepared.keptEncryptedData {
+		encryptedData[key] = value
+	}
+
+	sealedSecret := prepared.sealedSecret
+	sealedSecret.Spec.EncryptedData = encryptedData
+
+	existingScope := ""
+	if prepared.existing != nil {
+		existingScope = getScope(*prepared.existing)
+	}
+
+	manifest, err := renderManifest(sealedSecret, newEncryptedData, prepared.droppedKeys, opts.Output, existingScope)
+	if err != nil {
+		return model.CreateResult{}, err
+	}
+
+	// the controller only takes over a live Secret that carries the managed
+	// annotation itself
+	markedManaged := false
+	if opts.Managed && prepared.liveSecret.Annotations[managedAnnotation] != "true" {
+		if err := s.markSecretManaged(ctx, opts.Namespace, opts.SecretName); err != nil {
+			return model.CreateResult{}, fmt.Errorf("failed to mark secret as managed: %w", err)
+		}
+		markedManaged = true
+	}
+
+	return model.CreateResult{
+		Manifest:        manifest,
+		DroppedKeys:     prepared.droppedKeys,
+		MarkedManaged:   markedManaged,
+		MetadataChanges: getMetadataChanges(prepared.existing, sealedSecret),
+		SecretKeys:      getSecretKeys(sealedSecret.Spec.Template, encryptedData),
+		ScopeChange:     prepared.scopeChange,
+		LintWarnings:    prepared.lintIssues,
+		RevealedValues:  revealedValues,
+		PublicValues:    publicValues,
+	}, nil
+}
+
+// sealing is the outcome of the steps before the encryption, which sealing
+// and its preview share. sealedSecret has no encrypted data yet.
+type sealing struct {
+	existing          *model.SealedSecret
+	liveSecret        *corev1.Secret
+	existingData      map[string][]byte
+	valuesToEncrypt   map[string][]byte
+	keptEncryptedData map[string]string
+	droppedKeys       []string
+	sealedSecret      model.SealedSecret
+	scopeChange       *model.ScopeChange
+	lintIssues        []model.LintIssue
+}
+
+// prepareSealing validates the values against the existing objects and works
+// out which values are sealed and which ciphertext is kept. A preview reports
+// scope changes instead of failing on them and leaves linting to the caller.
+func (s SealedSecretService) prepareSealing(ctx context.Context, opts model.CreateOpts, preview bool) (sealing, error) {
+	if err := validateSecret(opts.Namespace, opts.SecretName, opts.Values); err != nil {
+		return sealing{}, err
+	}
+
+	if err := validateMetadata(opts); err != nil {
+		return sealing{}, err
 	}
 
-	scopeAnnotations := getScopeAnnotations(req.scope)
-	sealedSecretAnnotations := copyStringMap(scopeAnnotations)
+	var lintIssues []model.LintIssue
+	if !preview {
+		var err error
+		lintIssues, err = s.lintSecret(opts)
+		if err != nil {
+			return sealing{}, err
+		}
+	}
 
-	preservedAnnotations, err := s.getSealedSecretAnnotations(ctx, opts.Namespace, opts.SecretName)
+	existingSealedSecret, err := s.getExistingSealedSecret(ctx, opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to get existing sealed-secret annotations: %w", err)
+		return sealing{}, fmt.Errorf("failed to get existing sealed secret: %w", err)
 	}
 
+	// changing the scope either breaks the consumers of a wider scope or
+	// weakens the protection of a narrower one, so it has to be confirmed
+	scopeChange := getScopeChange(existingSealedSecret, opts.Scope)
+	if scopeChange != nil && !opts.ConfirmScopeChange && !preview {
+		return sealing{}, model.ScopeChangeError{ScopeChange: *scopeChange}
+	}
+
+	// merge-sealed does not read the plaintext Secret, unless it is to be
+	// taken over
+	var liveSecret *corev1.Secret
+	if opts.Mode != "merge-sealed" || opts.Managed {
+		liveSecret, err = s.getSecret(ctx, opts.Namespace, opts.SecretName)
+		if err != nil {
+			return sealing{}, fmt.Errorf("failed to get existing secret: %w", err)
+		}
+	}
+
+	var existingData map[string][]byte
+	var resultingData map[string][]byte
+	var valuesToEncrypt map[string][]byte
+	var keptEncryptedData map[string]string
+	droppedKeys := []string{}
+
+	switch opts.Mode {
+	case "merge-sealed":
+		// the keys that are not submitted keep their current ciphertext, so the
+		// plaintext Secret never needs to be read
+		existingEncryptedData, err := getSealedData(existingSealedSecret, opts.Scope)
+		if err != nil {
+			return sealing{}, err
+		}
+
+		valuesToEncrypt = opts.Values
+		keptEncryptedData = withoutKeys(existingEncryptedData, opts.Values)
+		if err := validateSecretSize(valuesToEncrypt, keptEncryptedData); err != nil {
+			return sealing{}, err
+		}
+	default:
+		if liveSecret != nil {
+			existingData = liveSecret.Data
+		}
+
+		valuesToEncrypt, droppedKeys = mergeValues(existingData, opts.Values, opts.Mode)
+		if err := validateSecretSize(valuesToEncrypt, nil); err != nil {
+			return sealing{}, err
+		}
+		resultingData = valuesToEncrypt
+
+		// unchanged values keep their current ciphertext to keep the diff of the
+		// manifest limited to the keys that actually changed
+		keptEncryptedData = getReusableEncryptedData(existingSealedSecret, opts.Scope, existingData, valuesToEncrypt)
+		valuesToEncrypt = withoutKeys(valuesToEncrypt, keptEncryptedData)
+	}
+
+	template := newTemplate(existingSealedSecret, opts)
+	keys := append(sortedKeys(valuesToEncrypt), sortedKeys(keptEncryptedData)...)
+	if err := validateTemplate(template, keys); err != nil {
+		return sealing{}, err
+	}
+
+	if err := validateLiveSecret(template, opts.Managed, liveSecret, resultingData); err != nil {
+		return sealing{}, err
+	}
+
+	return sealing{
+		existing:          existingSealedSecret,
+		liveSecret:        liveSecret,
+		existingData:      existingData,
+		valuesToEncrypt:   valuesToEncrypt,
+		keptEncryptedData: keptEncryptedData,
+		droppedKeys:       droppedKeys,
+		sealedSecret: model.SealedSecret{
+			APIVersion: "bitnami.com/v1alpha1",
+			Kind:       "SealedSecret",
+			Metadata:   s.newMetadata(existingSealedSecret, opts),
+			Spec:       model.SealedSecretSpec{Template: template},
+		},
+		scopeChange: scopeChange,
+		lintIssues:  lintIssues,
+	}, nil
+}
+
+// newMetadata returns the metadata of the SealedSecret with the scope
+// annotations of the requested scope and the preserved metadata of the
+// existing one.
+func (s SealedSecretService) newMetadata(existing *model.SealedSecret, opts model.CreateOpts) model.Metadata {
+	preservedAnnotations, preservedLabels := s.getPreservedMetadata(existing)
+
+	annotations := copyStringMap(getScopeAnnotations(opts.Scope))
 	for key, value := range preservedAnnotations {
 		if isScopeAnnotation(key) {
 			continue
 		}
-		sealedSecretAnnotations[key] = value
+		annotations[key] = value
 	}
 
-	sealedSecret := model.SealedSecret{
-		APIVersion: "bitnami.com/v1alpha1",
-		Kind:       "SealedSecret",
-		Metadata: model.Metadata{
-			Name:        req.secretName,
-			Namespace:   req.namespace,
-			Annotations: sealedSecretAnnotations,
-		},
-		Spec: model.SealedSecretSpec{
-			EncryptedData: encryptedData,
-			Template: model.Template{
-				Metadata: model.Metadata{
-					Name:        req.secretName,
-					Namespace:   req.namespace,
-					Annotations: scopeAnnotations,
-				},
-			},
-		},
+	return model.Metadata{
+		Name:        opts.SecretName,
+		Namespace:   opts.Namespace,
+		Labels:      mergeStringMap(preservedLabels, opts.SealedSecretLabels),
+		Annotations: annotations,
+	}
+}
+
+// extendSealedSecret adds the submitted values to the given SealedSecret
+// manifest. Its metadata, scope and encrypted data are kept as they are. The
+// cluster is only contacted for the public key when no certificate is given.
+func (s SealedSecretService) extendSealedSecret(ctx context.Context, opts model.CreateOpts) (model.CreateResult, error) {
+	prepared, err := s.prepareExtension(opts, false)
+	if err != nil {
+		return model.CreateResult{}, err
+	}
+
+	sealedSecret := prepared.sealedSecret
+
+	// with the certificate of the controller the manifest is extended without
+	// contacting the cluster
+	pubKey, err := s.resolvePublicKey(ctx, opts.Certificate)
+	if err != nil {
+		return model.CreateResult{}, fmt.Errorf("failed to get public key: %w", err)
+	}
+
+	req := encryptRequest{
+		pubKey:     pubKey,
+		secretName: sealedSecret.Metadata.Name,
+		namespace:  sealedSecret.Metadata.Namespace,
+		values:     prepared.valuesToEncrypt,
+		scope:      getScope(sealedSecret),
+	}
+
+	newEncryptedData, err := s.encryptValues(req)
+	if err != nil {
+		return model.CreateResult{}, err
+	}
+
+	encryptedData := copyStringMap(newEncryptedData)
+	for key, value := range prepared.keptEncryptedData {
+		encryptedData[key] = value
+	}
+	sealedSecret.Spec.EncryptedData = encryptedData
+
+	// the scope of a pasted manifest is kept, so it never changes
+	manifest, err := renderManifest(sealedSecret, newEncryptedData, prepared.droppedKeys, opts.Output, "")
+	if err != nil {
+		return model.CreateResult{}, err
+	}
+
+	return model.CreateResult{
+		Manifest:     manifest,
+		DroppedKeys:  prepared.droppedKeys,
+		SecretKeys:   getSecretKeys(sealedSecret.Spec.Template, encryptedData),
+		LintWarnings: prepared.lintIssues,
+	}, nil
+}
+
+// prepareExtension is prepareSealing for a pasted manifest. Its values cannot
+// be read, so the submitted keys it already has are sealed again.
+func (s SealedSecretService) prepareExtension(opts model.CreateOpts, preview bool) (sealing, error) {
+	existing, err := parseManifest(opts.Manifest)
+	if err != nil {
+		return sealing{}, err
+	}
+
+	// parsed once more, so the updates do not share the maps of the original
+	sealedSecret, err := parseManifest(opts.Manifest)
+	if err != nil {
+		return sealing{}, err
+	}
+
+	if err := validateSecret(sealedSecret.Metadata.Namespace, sealedSecret.Metadata.Name, opts.Values); err != nil {
+		return sealing{}, err
 	}
 
-	yamlData, err := yaml.Marshal(sealedSecret)
+	if err := validateMetadata(opts); err != nil {
+		return sealing{}, err
+	}
+
+	if opts.Managed {
+		return sealing{}, model.ValidationError{Errors: []model.FieldError{{
+			Field:   "behaviour",
+			Message: fmt.Sprintf("a pasted manifest is extended without the cluster, annotate the live Secret with %s: \"true\" for the controller to take it over", managedAnnotation),
+		}}}
+	}
+
+	var lintIssues []model.LintIssue
+	if !preview {
+		lintIssues, err = s.lintSecret(opts)
+		if err != nil {
+			return sealing{}, err
+		}
+	}
+
+	keptEncryptedData := withoutKeys(sealedSecret.Spec.EncryptedData, opts.Values)
+	droppedKeys := []string{}
+	if opts.Mode == "replace" {
+		for key := range keptEncryptedData {
+			droppedKeys = append(droppedKeys, key)
+		}
+		sort.Strings(droppedKeys)
+		keptEncryptedData = nil
+	}
+
+	if err := validateSecretSize(opts.Values, keptEncryptedData); err != nil {
+		return sealing{}, err
+	}
+
+	applyTemplateOpts(&sealedSecret.Spec.Template, opts)
+	sealedSecret.Metadata.Labels = mergeStringMap(sealedSecret.Metadata.Labels, opts.SealedSecretLabels)
+	sealedSecret.Spec.EncryptedData = nil
+	keys := append(sortedKeys(opts.Values), sortedKeys(keptEncryptedData)...)
+	if err := validateTemplate(sealedSecret.Spec.Template, keys); err != nil {
+		return sealing{}, err
+	}
+
+	return sealing{
+		existing:          &existing,
+		valuesToEncrypt:   opts.Values,
+		keptEncryptedData: keptEncryptedData,
+		droppedKeys:       droppedKeys,
+		sealedSecret:      sealedSecret,
+		lintIssues:        lintIssues,
+	}, nil
+}
+
+// getExistingSealedSecret returns the live SealedSecret or nil if it does not
+// exist. Only the "merge-sealed" mode depends on it, the other modes continue
+// without it when the service account is not allowed to read it.
+func (s SealedSecretService) getExistingSealedSecret(ctx context.Context, opts model.CreateOpts) (*model.SealedSecret, error) {
+	sealedSecret, err := s.getSealedSecret(ctx, opts.Namespace, opts.SecretName)
+	if apierrors.IsForbidden(err) && opts.Mode != "merge-sealed" {
+		log.Warn().Err(err).Msg("not allowed to read the existing sealed secret")
+		return nil, nil
+	}
+
+	return sealedSecret, err
+}
+
+func getScopeChange(sealedSecret *model.SealedSecret, scope string) *model.ScopeChange {
+	if sealedSecret == nil {
+		return nil
+	}
+
+	if existingScope := getScope(*sealedSecret); existingScope != scope {
+		return &model.ScopeChange{From: existingScope, To: scope}
+	}
+
+	return nil
+}
+
+// GetExistingScope returns the scope of the existing SealedSecret, or an
+// empty string if there is none.
+func (s SealedSecretService) GetExistingScope(ctx context.Context, namespace, secretName string) (string, error) {
+	if namespace == "" || secretName == "" {
+		return "", nil
+	}
+
+	sealedSecret, err := s.getSealedSecret(ctx, namespace, secretName)
+	if err != nil || sealedSecret == nil {
+		return "", err
+	}
+
+	return getScope(*sealedSecret), nil
+}
+
+// getSealedData returns the encrypted data of the live SealedSecret. The
+// ciphertext is bound to the scope it was sealed with, so it can only be
+// reused when the requested scope matches.
+func getSealedData(sealedSecret *model.SealedSecret, scope string) (map[string]string, error) {
+	if sealedSecret == nil {
+		return nil, nil
+	}
+
+	if existingScope := getScope(*sealedSecret); existingScope != scope {
+		return nil, model.ValidationError{Errors: []model.FieldError{{
+			Field:   "scope",
+			Message: fmt.Sprintf("the existing sealed secret was sealed with the %s scope and cannot be merged into the %s scope", existingScope, scope),
+		}}}
+	}
+
+	return sealedSecret.Spec.EncryptedData, nil
+}
+
+// getReusableEncryptedData returns the ciphertext of the live SealedSecret for
+// the values that did not change. Reusing is best effort, the values are
+// sealed again when there is no SealedSecret or it uses another scope.
+func getReusableEncryptedData(sealedSecret *model.SealedSecret, scope string, existingData, values map[string][]byte) map[string]string {
+	if sealedSecret == nil || getScope(*sealedSecret) != scope {
+		return nil
+	}
+
+	return reusableEncryptedData(existingData, values, sealedSecret.Spec.EncryptedData)
+}
+
+// getPreservedMetadata returns the annotations and labels of the existing
+// SealedSecret that match the preserve rules of its namespace.
+func (s SealedSecretService) getPreservedMetadata(sealedSecret *model.SealedSecret) (map[string]string, map[string]string) {
+	if sealedSecret == nil {
+		return nil, nil
+	}
+
+	annotationMatcher, labelMatcher := s.preserveConfig.matchersFor(sealedSecret.Metadata.Namespace)
+
+	return annotationMatcher.filter(sealedSecret.Metadata.Annotations), labelMatcher.filter(sealedSecret.Metadata.Labels)
+}
+
+// ResolvePreservedMetadata returns the preserve rules of the namespace and the
+// metadata of the existing SealedSecret they select.
+func (s SealedSecretService) ResolvePreservedMetadata(ctx context.Context, namespace, secretName string) (model.PreservedMetadata, error) {
+	rules := s.preserveConfig.rulesFor(namespace)
+	result := model.PreservedMetadata{
+		AnnotationRules: rules.Annotations,
+		LabelRules:      rules.Labels,
+	}
+
+	if namespace == "" || secretName == "" {
+		return result, nil
+	}
+
+	sealedSecret, err := s.getSealedSecret(ctx, namespace, secretName)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal sealed secret to YAML: %w", err)
+		return result, err
+	}
+
+	result.Annotations, result.Labels = s.getPreservedMetadata(sealedSecret)
+	for key := range result.Annotations {
+		if isScopeAnnotation(key) {
+			delete(result.Annotations, key)
+		}
 	}
 
-	return string(yamlData), nil
+	return result, nil
+}
+
+func (s SealedSecretService) MergeSealedSecrets(_ context.Context, opts model.MergeOpts) (model.MergeResult, error) {
+	return MergeManifests(opts)
 }
 
 func (s SealedSecretService) ListNamespaces(ctx context.Context) ([]string, error) {
@@ -170,6 +567,70 @@ func (s SealedSecretService) encryptValues(req encryptRequest) (map[string]strin
 	return encryptedData, nil
 }
 
+// mergeValues combines the existing secret data with the submitted values. In
+// "replace" mode the existing data is discarded and the keys that are not
+// submitted again are returned as dropped.
+func mergeValues(existing, submitted map[string][]byte, mode string) (map[string][]byte, []string) {
+	results := make(map[string][]byte, len(existing)+len(submitted))
+	droppedKeys := []string{}
+
+	switch mode {
+	case "replace":
+		for key := range existing {
+			if _, ok := submitted[key]; !ok {
+				droppedKeys = append(droppedKeys, key)
+			}
+		}
+	default:
+		// we need to encrypt all the existing data as well as the new data
+		for key, value := range existing {
+			results[key] = value
+		}
+	}
+
+	for key, value := range submitted {
+		results[key] = value
+	}
+
+	sort.Strings(droppedKeys)
+	return results, droppedKeys
+}
+
+func withoutKeys[V, K any](source map[string]V, keys map[string]K) map[string]V {
+	results := make(map[string]V, len(source))
+	for key, value := range source {
+		if _, ok := keys[key]; ok {
+			continue
+		}
+		results[key] = value
+	}
+
+	return results
+}
+
+// reusableEncryptedData returns the existing ciphertext of every value that
+// equals the value of the current secret.
+func reusableEncryptedData(existingData, values map[string][]byte, encryptedData map[string]string) map[string]string {
+	results := make(map[string]string)
+	for key, value := range values {
+		existingValue, ok := existingData[key]
+		if !ok {
+			continue
+		}
+
+		encryptedValue, ok := encryptedData[key]
+		if !ok {
+			continue
+		}
+
+		if subtle.ConstantTimeCompare(value, existingValue) == 1 {
+			results[key] = encryptedValue
+		}
+	}
+
+	return results
+}
+
 func toStringSet(values []string) map[string]struct{} {
 	results := make(map[string]struct{}, len(values))
 	for _, value := range values {
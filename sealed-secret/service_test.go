@@ -1,9 +1,18 @@
 package sealedsecret
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
 	"testing"
+	"time"
 
+	"github.com/atom363/sealed-secrets-ui/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestToStringSet(t *testing.T) {
@@ -50,3 +59,252 @@ func TestGetScopeAnnotations(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeValues(t *testing.T) {
+	existing := map[string][]byte{
+		"API_TOKEN":   []byte("old-token"),
+		"PG_PASSWORD": []byte("old-password"),
+	}
+	submitted := map[string][]byte{
+		"API_TOKEN": []byte("new-token"),
+		"NEW_KEY":   []byte("new-value"),
+	}
+
+	tcs := []struct {
+		name        string
+		mode        string
+		want        map[string][]byte
+		wantDropped []string
+	}{
+		{
+			name: "merge",
+			mode: "merge",
+			want: map[string][]byte{
+				"API_TOKEN":   []byte("new-token"),
+				"PG_PASSWORD": []byte("old-password"),
+				"NEW_KEY":     []byte("new-value"),
+			},
+			wantDropped: []string{},
+		},
+		{
+			name: "empty mode defaults to merge",
+			mode: "",
+			want: map[string][]byte{
+				"API_TOKEN":   []byte("new-token"),
+				"PG_PASSWORD": []byte("old-password"),
+				"NEW_KEY":     []byte("new-value"),
+			},
+			wantDropped: []string{},
+		},
+		{
+			name: "replace",
+			mode: "replace",
+			want: map[string][]byte{
+				"API_TOKEN": []byte("new-token"),
+				"NEW_KEY":   []byte("new-value"),
+			},
+			wantDropped: []string{"PG_PASSWORD"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, gotDropped := mergeValues(existing, submitted, tc.mode)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantDropped, gotDropped)
+		})
+	}
+}
+
+func TestWithoutKeys(t *testing.T) {
+	got := withoutKeys(
+		map[string]string{"API_TOKEN": "AgA...", "PG_PASSWORD": "AgB..."},
+		map[string]string{"API_TOKEN": "new-token"},
+	)
+
+	assert.Equal(t, map[string]string{"PG_PASSWORD": "AgB..."}, got)
+}
+
+func TestReusableEncryptedData(t *testing.T) {
+	existingData := map[string][]byte{
+		"API_TOKEN":   []byte("token"),
+		"PG_PASSWORD": []byte("old-password"),
+		"UNSEALED":    []byte("value"),
+	}
+	values := map[string][]byte{
+		"API_TOKEN":   []byte("token"),
+		"PG_PASSWORD": []byte("new-password"),
+		"UNSEALED":    []byte("value"),
+		"NEW_KEY":     []byte("new-value"),
+	}
+	encryptedData := map[string]string{
+		"API_TOKEN":   "AgA...",
+		"PG_PASSWORD": "AgB...",
+	}
+
+	got := reusableEncryptedData(existingData, values, encryptedData)
+
+	assert.Equal(t, map[string]string{"API_TOKEN": "AgA..."}, got)
+}
+
+func TestValidateSecretType(t *testing.T) {
+	tcs := []struct {
+		name       string
+		secretType string
+		keys       []string
+		isWantErr  bool
+	}{
+		{
+			name:       "opaque",
+			secretType: "",
+			keys:       []string{"API_TOKEN"},
+		},
+		{
+			name:       "tls",
+			secretType: "kubernetes.io/tls",
+			keys:       []string{"tls.crt", "tls.key"},
+		},
+		{
+			name:       "tls without key",
+			secretType: "kubernetes.io/tls",
+			keys:       []string{"tls.crt"},
+			isWantErr:  true,
+		},
+		{
+			name:       "basic-auth with password only",
+			secretType: "kubernetes.io/basic-auth",
+			keys:       []string{"password"},
+		},
+		{
+			name:       "dockerconfigjson without config",
+			secretType: "kubernetes.io/dockerconfigjson",
+			keys:       []string{"config.json"},
+			isWantErr:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateSecretType(tc.secretType, tc.keys)
+			if tc.isWantErr {
+				var validationErr model.ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, "type", validationErr.Errors[0].Field)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetSealedDataRejectsOtherScope(t *testing.T) {
+	sealedSecret := &model.SealedSecret{Metadata: model.Metadata{
+		Annotations: map[string]string{"sealedsecrets.bitnami.com/namespace-wide": "true"},
+	}}
+
+	_, err := getSealedData(sealedSecret, "strict")
+	var validationErr model.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []model.FieldError{{
+		Field:   "scope",
+		Message: "the existing sealed secret was sealed with the namespace scope and cannot be merged into the strict scope",
+	}}, validationErr.Errors)
+}
+
+func TestGetScopeChange(t *testing.T) {
+	clusterWide := &model.SealedSecret{
+		Metadata: model.Metadata{
+			Annotations: map[string]string{"sealedsecrets.bitnami.com/cluster-wide": "true"},
+		},
+	}
+
+	tcs := []struct {
+		name         string
+		sealedSecret *model.SealedSecret
+		scope        string
+		want         *model.ScopeChange
+	}{
+		{
+			name:  "no existing sealed secret",
+			scope: "strict",
+		},
+		{
+			name:         "same scope",
+			sealedSecret: clusterWide,
+			scope:        "cluster",
+		},
+		{
+			name:         "narrowed scope",
+			sealedSecret: clusterWide,
+			scope:        "strict",
+			want:         &model.ScopeChange{From: "cluster", To: "strict"},
+		},
+		{
+			name:         "widened scope",
+			sealedSecret: &model.SealedSecret{},
+			scope:        "namespace",
+			want:         &model.ScopeChange{From: "strict", To: "namespace"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := getScopeChange(tc.sealedSecret, tc.scope)
+
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestCreateSealedSecretExtendsManifestOffline(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	// the service has no clients, so any access to the cluster would fail
+	result, err := SealedSecretService{}.CreateSealedSecret(context.Background(), model.CreateOpts{
+		Values: map[string][]byte{"API_TOKEN": []byte("token")},
+		Manifest: `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: api
+  namespace: payments
+spec:
+  encryptedData:
+    PG_PASSWORD: AgBy3i4OJSWK
+`,
+		Certificate:         string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		AcknowledgeWarnings: true,
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, result.Manifest, "PG_PASSWORD: AgBy3i4OJSWK")
+	assert.Contains(t, result.Manifest, "API_TOKEN: ")
+}
+
+func TestResolvePublicKeyRejectsInvalidCertificate(t *testing.T) {
+	_, err := SealedSecretService{}.resolvePublicKey(context.Background(), "not a certificate")
+
+	var validationErr model.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []model.FieldError{{Field: "certificate", Message: "failed to decode PEM block containing certificate"}}, validationErr.Errors)
+}
+
+func TestPrepareExtensionRejectsManaged(t *testing.T) {
+	_, err := SealedSecretService{}.prepareExtension(model.CreateOpts{
+		Values:  map[string][]byte{"API_TOKEN": []byte("token")},
+		Managed: true,
+		Manifest: `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: api
+  namespace: payments
+`,
+	}, false)
+
+	var validationErr model.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "behaviour", validationErr.Errors[0].Field)
+}
@@ -0,0 +1,397 @@
+package sealedsecret
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/template/parse"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	managedAnnotation                = "sealedsecrets.bitnami.com/managed"
+	patchAnnotation                  = "sealedsecrets.bitnami.com/patch"
+	skipSetOwnerReferencesAnnotation = "sealedsecrets.bitnami.com/skip-set-owner-references"
+)
+
+// requiredSecretKeys lists the keys the API server requires for the built-in
+// secret types. At least one key of every group has to be present.
+var requiredSecretKeys = map[string][][]string{
+	"kubernetes.io/basic-auth":       {{"username", "password"}},
+	"kubernetes.io/dockercfg":        {{".dockercfg"}},
+	"kubernetes.io/dockerconfigjson": {{".dockerconfigjson"}},
+	"kubernetes.io/ssh-auth":         {{"ssh-privatekey"}},
+	"kubernetes.io/tls":              {{"tls.crt"}, {"tls.key"}},
+}
+
+// validateTemplate checks the template against the keys of the encrypted
+// data.
+func validateTemplate(template model.Template, keys []string) error {
+	// the templated keys end up in the Secret too, so they count towards the
+	// keys its type requires
+	if err := validateSecretType(template.Type, append(sortedKeys(template.Data), keys...)); err != nil {
+		return err
+	}
+
+	if err := validateTemplateData(template.Data, keys); err != nil {
+		return err
+	}
+
+	return validateBehaviour(template)
+}
+
+func validateSecretType(secretType string, keys []string) error {
+	missing := []string{}
+	for _, group := range requiredSecretKeys[secretType] {
+		if !containsAnyKey(group, keys) {
+			missing = append(missing, strings.Join(group, " or "))
+		}
+	}
+
+	if len(missing) > 0 {
+		return model.ValidationError{Errors: []model.FieldError{{
+			Field:   "type",
+			Message: fmt.Sprintf("secret type %s requires the key(s) %s", secretType, strings.Join(missing, ", ")),
+		}}}
+	}
+
+	return nil
+}
+
+func containsAnyKey(candidates, keys []string) bool {
+	for _, candidate := range candidates {
+		if slices.Contains(keys, candidate) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newTemplate returns the template for the generated Secret. The template of
+// the existing SealedSecret is carried forward, options that are given
+// override it.
+func newTemplate(existing *model.SealedSecret, opts model.CreateOpts) model.Template {
+	template := model.Template{}
+	if existing != nil {
+		template = existing.Spec.Template
+	}
+
+	annotations := getScopeAnnotations(opts.Scope)
+	for key, value := range template.Metadata.Annotations {
+		if isScopeAnnotation(key) {
+			continue
+		}
+		annotations[key] = value
+	}
+
+	template.Metadata = model.Metadata{
+		Name:        opts.SecretName,
+		Namespace:   opts.Namespace,
+		Labels:      template.Metadata.Labels,
+		Annotations: annotations,
+	}
+
+	applyTemplateOpts(&template, opts)
+	return template
+}
+
+// applyTemplateOpts sets the template options. Options that are not given
+// keep the values of the template, unless the template is replaced.
+func applyTemplateOpts(template *model.Template, opts model.CreateOpts) {
+	if opts.ReplaceTemplate {
+		template.Type = opts.Type
+		template.Immutable = opts.Immutable
+		template.Metadata.Labels = copyStringMap(opts.Labels)
+		template.Data = copyStringMap(opts.TemplateData)
+
+		// the other annotations are not part of the form, they are kept. The
+		// managed annotation has no effect on the template, it is dropped too
+		annotations := copyStringMap(template.Metadata.Annotations)
+		for _, key := range []string{managedAnnotation, patchAnnotation, skipSetOwnerReferencesAnnotation} {
+			delete(annotations, key)
+		}
+		template.Metadata.Annotations = annotations
+	}
+
+	if opts.Type != "" {
+		template.Type = opts.Type
+	}
+
+	if opts.Immutable {
+		template.Immutable = true
+	}
+
+	template.Metadata.Labels = mergeStringMap(template.Metadata.Labels, opts.Labels)
+	template.Metadata.Annotations = mergeStringMap(template.Metadata.Annotations, opts.Annotations)
+	template.Metadata.Annotations = mergeStringMap(template.Metadata.Annotations, getBehaviourAnnotations(opts))
+	template.Data = mergeStringMap(template.Data, opts.TemplateData)
+}
+
+// getBehaviourAnnotations returns the annotations that control how the
+// controller writes the generated Secret. The controller reads them from the
+// Secret it generates, so they belong to the template. The managed annotation
+// is different, the controller reads it from the live Secret it takes over,
+// see markSecretManaged.
+func getBehaviourAnnotations(opts model.CreateOpts) map[string]string {
+	annotations := make(map[string]string)
+	if opts.Patch {
+		annotations[patchAnnotation] = "true"
+	}
+
+	if opts.SkipSetOwnerReferences {
+		annotations[skipSetOwnerReferencesAnnotation] = "true"
+	}
+
+	return annotations
+}
+
+// validateBehaviour rejects behaviour annotations that contradict the
+// template.
+func validateBehaviour(template model.Template) error {
+	if template.Metadata.Annotations[patchAnnotation] == "true" && template.Immutable {
+		return model.ValidationError{Errors: []model.FieldError{{Field: "behaviour", Message: "an immutable secret cannot be patched"}}}
+	}
+
+	return nil
+}
+
+// validateLiveSecret rejects the options the controller ignores or fails to
+// apply to the live Secret, which is nil when it does not exist. values are
+// the values the Secret ends up with, nil when they are not known.
+func validateLiveSecret(template model.Template, managed bool, secret *corev1.Secret, values map[string][]byte) error {
+	if managed && secret == nil {
+		return model.ValidationError{Errors: []model.FieldError{{Field: "behaviour", Message: "there is no live Secret for the controller to take over"}}}
+	}
+
+	if secret == nil || secret.Immutable == nil || !*secret.Immutable {
+		return nil
+	}
+
+	// the API server rejects both, the Secret has to be deleted first
+	if !template.Immutable {
+		return model.ValidationError{Errors: []model.FieldError{{Field: "immutable", Message: "the live Secret is immutable and cannot be made mutable, delete it first"}}}
+	}
+
+	if values != nil && !sameData(secret.Data, values) {
+		return model.ValidationError{Errors: []model.FieldError{{Field: "immutable", Message: "the live Secret is immutable and its values cannot change, delete it first"}}}
+	}
+
+	return nil
+}
+
+func sameData(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for key, value := range a {
+		other, ok := b[key]
+		if !ok || !sameValue(value, other) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateTemplateData checks that the templates parse and only reference
+// keys of the encrypted data.
+func validateTemplateData(data map[string]string, keys []string) error {
+	for _, name := range sortedKeys(data) {
+		references, err := getTemplateReferences(name, data[name])
+		if err != nil {
+			return model.ValidationError{Errors: []model.FieldError{{
+				Field:   "templateData",
+				Message: fmt.Sprintf("failed to parse template %s: %v", name, err),
+			}}}
+		}
+
+		missing := []string{}
+		for _, reference := range references {
+			if !slices.Contains(keys, reference) {
+				missing = append(missing, reference)
+			}
+		}
+
+		if len(missing) > 0 {
+			return model.ValidationError{Errors: []model.FieldError{{
+				Field:   "templateData",
+				Message: fmt.Sprintf("template %s references unknown key(s) %s", name, strings.Join(missing, ", ")),
+			}}}
+		}
+	}
+
+	return nil
+}
+
+// getTemplateReferences returns the keys a template references, either as
+// {{ .key }} or as {{ index . "key" }}. Functions are not checked, the
+// controller provides more of them than the standard library.
+func getTemplateReferences(name, text string) ([]string, error) {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	if _, err := tree.Parse(text, "", "", map[string]*parse.Tree{}); err != nil {
+		return nil, err
+	}
+
+	references := make(map[string]struct{})
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch node := node.(type) {
+		case *parse.ListNode:
+			if node == nil {
+				return
+			}
+			for _, child := range node.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(node.Pipe)
+		case *parse.PipeNode:
+			if node == nil {
+				return
+			}
+			for _, command := range node.Cmds {
+				walk(command)
+			}
+		case *parse.CommandNode:
+			if len(node.Args) == 3 && isIdentifier(node.Args[0], "index") && node.Args[1].Type() == parse.NodeDot {
+				if key, ok := node.Args[2].(*parse.StringNode); ok {
+					references[key.Text] = struct{}{}
+				}
+			}
+			for _, arg := range node.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			references[node.Ident[0]] = struct{}{}
+		case *parse.VariableNode:
+			if len(node.Ident) > 1 && node.Ident[0] == "$" {
+				references[node.Ident[1]] = struct{}{}
+			}
+		case *parse.IfNode:
+			walk(node.Pipe)
+			walk(node.List)
+			walk(node.ElseList)
+		case *parse.RangeNode:
+			walk(node.Pipe)
+			walk(node.List)
+			walk(node.ElseList)
+		case *parse.WithNode:
+			walk(node.Pipe)
+			walk(node.List)
+			walk(node.ElseList)
+		}
+	}
+	walk(tree.Root)
+
+	return sortedKeys(references), nil
+}
+
+func isIdentifier(node parse.Node, name string) bool {
+	identifier, ok := node.(*parse.IdentifierNode)
+	return ok && identifier.Ident == name
+}
+
+// getSecretKeys lists the keys of the Secret the controller generates.
+func getSecretKeys(template model.Template, encryptedData map[string]string) []model.SecretKey {
+	keys := make(map[string]bool, len(encryptedData)+len(template.Data))
+	for key := range encryptedData {
+		keys[key] = false
+	}
+
+	for key := range template.Data {
+		keys[key] = true
+	}
+
+	results := make([]model.SecretKey, 0, len(keys))
+	for _, key := range sortedKeys(keys) {
+		results = append(results, model.SecretKey{Name: key, Templated: keys[key]})
+	}
+
+	return results
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	return slices.Sorted(maps.Keys(values))
+}
+
+// getMetadataChanges lists the metadata of the existing SealedSecret that the
+// updated one changes or loses.
+func getMetadataChanges(existing *model.SealedSecret, updated model.SealedSecret) []model.MetadataChange {
+	if existing == nil {
+		return nil
+	}
+
+	changes := []model.MetadataChange{}
+	changes = appendMapChanges(changes, "metadata.labels", existing.Metadata.Labels, updated.Metadata.Labels)
+	changes = appendMapChanges(changes, "metadata.annotations", existing.Metadata.Annotations, updated.Metadata.Annotations)
+
+	existingTemplate, updatedTemplate := existing.Spec.Template, updated.Spec.Template
+	if existingTemplate.Type != "" && existingTemplate.Type != updatedTemplate.Type {
+		changes = append(changes, model.MetadataChange{Field: "spec.template.type", Old: existingTemplate.Type, New: updatedTemplate.Type})
+	}
+
+	if existingTemplate.Immutable && !updatedTemplate.Immutable {
+		changes = append(changes, model.MetadataChange{Field: "spec.template.immutable", Old: "true"})
+	}
+
+	changes = appendMapChanges(changes, "spec.template.metadata.labels", existingTemplate.Metadata.Labels, updatedTemplate.Metadata.Labels)
+	changes = appendMapChanges(changes, "spec.template.metadata.annotations", existingTemplate.Metadata.Annotations, updatedTemplate.Metadata.Annotations)
+	changes = appendMapChanges(changes, "spec.template.data", existingTemplate.Data, updatedTemplate.Data)
+
+	for key := range existingTemplate.Rest {
+		if _, ok := updatedTemplate.Rest[key]; !ok {
+			changes = append(changes, model.MetadataChange{Field: "spec.template." + key, Old: fmt.Sprint(existingTemplate.Rest[key])})
+		}
+	}
+
+	return changes
+}
+
+func appendMapChanges(changes []model.MetadataChange, field string, existing, updated map[string]string) []model.MetadataChange {
+	for _, key := range sortedKeys(existing) {
+		if isSystemAnnotation(key) {
+			continue
+		}
+
+		if value, ok := updated[key]; !ok || value != existing[key] {
+			changes = append(changes, model.MetadataChange{
+				Field: fmt.Sprintf("%s[%s]", field, key),
+				Old:   existing[key],
+				New:   value,
+			})
+		}
+	}
+
+	return changes
+}
+
+func mergeStringMap(target, source map[string]string) map[string]string {
+	if len(source) == 0 {
+		return target
+	}
+
+	results := copyStringMap(target)
+	for key, value := range source {
+		results[key] = value
+	}
+
+	return results
+}
+
+// isSystemAnnotation reports whether the annotation is maintained by
+// Kubernetes or its tooling rather than by the users of the secret.
+func isSystemAnnotation(annotationKey string) bool {
+	for _, prefix := range []string{"kubectl.kubernetes.io/", "kubernetes.io/", "meta.helm.sh/"} {
+		if strings.HasPrefix(annotationKey, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,348 @@
+package sealedsecret
+
+import (
+	"testing"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNewTemplateCarriesExistingTemplateForward(t *testing.T) {
+	existing, err := parseSealedSecret([]byte(`apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: app
+  namespace: default
+spec:
+  template:
+    metadata:
+      labels:
+        team: payments
+      annotations:
+        sealedsecrets.bitnami.com/cluster-wide: "true"
+        reloader.stakater.com/match: "true"
+    type: kubernetes.io/basic-auth
+    immutable: true
+    data:
+      url: https://{{ .username }}@example.com
+    unmodelled: value
+`))
+	require.NoError(t, err)
+
+	got := newTemplate(&existing, model.CreateOpts{
+		Scope:      "strict",
+		Namespace:  "default",
+		SecretName: "app",
+		Labels:     map[string]string{"app": "web"},
+	})
+
+	assert.Equal(t, model.Metadata{
+		Name:        "app",
+		Namespace:   "default",
+		Labels:      map[string]string{"team": "payments", "app": "web"},
+		Annotations: map[string]string{"reloader.stakater.com/match": "true"},
+	}, got.Metadata)
+	assert.Equal(t, "kubernetes.io/basic-auth", got.Type)
+	assert.True(t, got.Immutable)
+	assert.Equal(t, map[string]string{"url": "https://{{ .username }}@example.com"}, got.Data)
+	assert.Contains(t, got.Rest, "unmodelled")
+}
+
+func TestNewTemplateReplacesExistingTemplate(t *testing.T) {
+	existing, err := parseSealedSecret([]byte(`apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: app
+  namespace: default
+spec:
+  template:
+    metadata:
+      labels:
+        team: payments
+        app: web
+      annotations:
+        reloader.stakater.com/match: "true"
+        sealedsecrets.bitnami.com/managed: "true"
+    type: kubernetes.io/basic-auth
+    immutable: true
+    data:
+      url: https://{{ .username }}@example.com
+`))
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name string
+		opts model.CreateOpts
+		want model.Template
+	}{
+		{
+			name: "remove a label and turn off immutable",
+			opts: model.CreateOpts{
+				Type:            "kubernetes.io/basic-auth",
+				Labels:          map[string]string{"app": "web"},
+				TemplateData:    map[string]string{"url": "https://{{ .username }}@example.com"},
+				ReplaceTemplate: true,
+			},
+			want: model.Template{
+				Metadata: model.Metadata{
+					Name:        "app",
+					Namespace:   "default",
+					Labels:      map[string]string{"app": "web"},
+					Annotations: map[string]string{"reloader.stakater.com/match": "true"},
+				},
+				Type: "kubernetes.io/basic-auth",
+				Data: map[string]string{"url": "https://{{ .username }}@example.com"},
+			},
+		},
+		{
+			name: "clear everything",
+			opts: model.CreateOpts{ReplaceTemplate: true},
+			want: model.Template{
+				Metadata: model.Metadata{
+					Name:        "app",
+					Namespace:   "default",
+					Labels:      map[string]string{},
+					Annotations: map[string]string{"reloader.stakater.com/match": "true"},
+				},
+				Data: map[string]string{},
+			},
+		},
+		{
+			name: "keep without replacing",
+			opts: model.CreateOpts{Labels: map[string]string{"app": "api"}},
+			want: model.Template{
+				Metadata: model.Metadata{
+					Name:      "app",
+					Namespace: "default",
+					Labels:    map[string]string{"team": "payments", "app": "api"},
+					Annotations: map[string]string{
+						"reloader.stakater.com/match":       "true",
+						"sealedsecrets.bitnami.com/managed": "true",
+					},
+				},
+				Type:      "kubernetes.io/basic-auth",
+				Immutable: true,
+				Data:      map[string]string{"url": "https://{{ .username }}@example.com"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.Scope = "strict"
+			tc.opts.Namespace = "default"
+			tc.opts.SecretName = "app"
+			assert.Equal(t, tc.want, newTemplate(&existing, tc.opts))
+		})
+	}
+}
+
+func TestGetTemplateReferences(t *testing.T) {
+	got, err := getTemplateReferences("config", `url: postgres://{{ .PG_USER }}:{{ index . "pg-password" }}@db
+{{ if .TLS }}sslmode: {{ $.SSL_MODE | upper }}{{ end }}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PG_USER", "SSL_MODE", "TLS", "pg-password"}, got)
+}
+
+func TestValidateTemplateData(t *testing.T) {
+	keys := []string{"PG_USER", "PG_PASSWORD"}
+
+	assert.NoError(t, validateTemplateData(map[string]string{
+		"DATABASE_URL": "postgres://{{ .PG_USER }}:{{ .PG_PASSWORD }}@db",
+	}, keys))
+	assert.Equal(t, model.ValidationError{Errors: []model.FieldError{{
+		Field:   "templateData",
+		Message: "template DATABASE_URL references unknown key(s) PG_PASS",
+	}}}, validateTemplateData(map[string]string{
+		"DATABASE_URL": "postgres://{{ .PG_USER }}:{{ .PG_PASS }}@db",
+	}, keys))
+	assert.Error(t, validateTemplateData(map[string]string{
+		"DATABASE_URL": "postgres://{{ .PG_USER",
+	}, keys))
+}
+
+func TestGetSecretKeys(t *testing.T) {
+	got := getSecretKeys(
+		model.Template{Data: map[string]string{"DATABASE_URL": "{{ .PG_USER }}"}},
+		map[string]string{"PG_USER": "AgA..."},
+	)
+
+	assert.Equal(t, []model.SecretKey{
+		{Name: "DATABASE_URL", Templated: true},
+		{Name: "PG_USER"},
+	}, got)
+}
+
+func TestGetMetadataChanges(t *testing.T) {
+	existing := model.SealedSecret{
+		Metadata: model.Metadata{
+			Annotations: map[string]string{
+				"argocd.argoproj.io/sync-wave":                     "1",
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+			},
+		},
+		Spec: model.SealedSecretSpec{
+			Template: model.Template{
+				Type:     "kubernetes.io/tls",
+				Metadata: model.Metadata{Labels: map[string]string{"team": "payments"}},
+			},
+		},
+	}
+	updated := model.SealedSecret{
+		Spec: model.SealedSecretSpec{
+			Template: model.Template{
+				Type:     "kubernetes.io/basic-auth",
+				Metadata: model.Metadata{Labels: map[string]string{"team": "payments"}},
+			},
+		},
+	}
+
+	got := getMetadataChanges(&existing, updated)
+
+	assert.Equal(t, []model.MetadataChange{
+		{Field: "metadata.annotations[argocd.argoproj.io/sync-wave]", Old: "1"},
+		{Field: "spec.template.type", Old: "kubernetes.io/tls", New: "kubernetes.io/basic-auth"},
+	}, got)
+}
+
+func TestValidateBehaviour(t *testing.T) {
+	tcs := []struct {
+		name      string
+		opts      model.CreateOpts
+		isWantErr bool
+	}{
+		{
+			name: "patch and skip owner references",
+			opts: model.CreateOpts{Patch: true, SkipSetOwnerReferences: true},
+		},
+		{
+			name:      "patch immutable",
+			opts:      model.CreateOpts{Patch: true, Immutable: true},
+			isWantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			template := newTemplate(nil, tc.opts)
+			err := validateBehaviour(template)
+			if tc.isWantErr {
+				var validationErr model.ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, "behaviour", validationErr.Errors[0].Field)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	tcs := []struct {
+		name      string
+		template  model.Template
+		keys      []string
+		isWantErr bool
+	}{
+		{
+			name: "basic-auth with templated password",
+			template: model.Template{
+				Type: "kubernetes.io/basic-auth",
+				Data: map[string]string{"password": "{{ .PG_PASSWORD }}"},
+			},
+			keys: []string{"PG_PASSWORD"},
+		},
+		{
+			name: "tls with templated certificate only",
+			template: model.Template{
+				Type: "kubernetes.io/tls",
+				Data: map[string]string{"tls.crt": "{{ .CERT }}"},
+			},
+			keys:      []string{"CERT"},
+			isWantErr: true,
+		},
+		{
+			name: "template with unknown key",
+			template: model.Template{
+				Data: map[string]string{"password": "{{ .PG_PASS }}"},
+			},
+			keys:      []string{"PG_PASSWORD"},
+			isWantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateTemplate(tc.template, tc.keys)
+			if tc.isWantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateLiveSecret(t *testing.T) {
+	immutable := true
+	immutableSecret := &corev1.Secret{Immutable: &immutable, Data: map[string][]byte{"PASSWORD": []byte("secret")}}
+
+	tcs := []struct {
+		name      string
+		template  model.Template
+		managed   bool
+		secret    *corev1.Secret
+		values    map[string][]byte
+		wantField string
+	}{
+		{
+			name:      "managed without live secret",
+			managed:   true,
+			wantField: "behaviour",
+		},
+		{
+			name:    "managed with live secret",
+			managed: true,
+			secret:  &corev1.Secret{},
+		},
+		{
+			name:      "immutable secret made mutable",
+			secret:    immutableSecret,
+			values:    map[string][]byte{"PASSWORD": []byte("secret")},
+			wantField: "immutable",
+		},
+		{
+			name:      "immutable secret with changed values",
+			template:  model.Template{Immutable: true},
+			secret:    immutableSecret,
+			values:    map[string][]byte{"PASSWORD": []byte("other")},
+			wantField: "immutable",
+		},
+		{
+			name:     "immutable secret unchanged",
+			template: model.Template{Immutable: true},
+			secret:   immutableSecret,
+			values:   map[string][]byte{"PASSWORD": []byte("secret")},
+		},
+		{
+			name:     "immutable secret with unknown values",
+			template: model.Template{Immutable: true},
+			secret:   immutableSecret,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateLiveSecret(tc.template, tc.managed, tc.secret, tc.values)
+			if tc.wantField != "" {
+				var validationErr model.ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, tc.wantField, validationErr.Errors[0].Field)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package sealedsecret
+
+import (
+	"fmt"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// maxSecretSize is the limit the Kubernetes API puts on the data of a Secret.
+const maxSecretSize = 1 << 20
+
+// validateSecret checks the names and keys the way the Kubernetes API does,
+// so a manifest it would reject is never generated.
+func validateSecret(namespace, secretName string, values map[string][]byte) error {
+	fieldErrors := append(nameErrors(namespace, secretName), valueErrors(values)...)
+	if len(fieldErrors) > 0 {
+		return model.ValidationError{Errors: fieldErrors}
+	}
+
+	return nil
+}
+
+// validateMetadata checks the labels and the keys of the template data the
+// way the Kubernetes API does.
+func validateMetadata(opts model.CreateOpts) error {
+	fieldErrors := append(labelErrors("labels", opts.Labels), labelErrors("sealedSecretLabels", opts.SealedSecretLabels)...)
+	for _, key := range sortedKeys(opts.TemplateData) {
+		for _, message := range validation.IsConfigMapKey(key) {
+			fieldErrors = append(fieldErrors, model.FieldError{Field: "templateData", Message: fmt.Sprintf("key %q: %s", key, message)})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return model.ValidationError{Errors: fieldErrors}
+	}
+
+	return nil
+}
+
+// validateSecretSize checks the size of the values the Secret ends up with,
+// along with the kept ciphertext whose values are not read.
+func validateSecretSize(values map[string][]byte, keptEncryptedData map[string]string) error {
+	size := dataSize(values)
+	for key, ciphertext := range keptEncryptedData {
+		size += len(key) + sealedValueSize(ciphertext)
+	}
+
+	if err := sizeError(size); err != nil {
+		return model.ValidationError{Errors: []model.FieldError{*err}}
+	}
+
+	return nil
+}
+
+func nameErrors(namespace, secretName string) []model.FieldError {
+	fieldErrors := []model.FieldError{}
+	for _, message := range validation.IsDNS1123Label(namespace) {
+		fieldErrors = append(fieldErrors, model.FieldError{Field: "namespace", Message: message})
+	}
+
+	for _, message := range validation.IsDNS1123Subdomain(secretName) {
+		fieldErrors = append(fieldErrors, model.FieldError{Field: "secretName", Message: message})
+	}
+
+	return fieldErrors
+}
+
+func labelErrors(field string, labels map[string]string) []model.FieldError {
+	fieldErrors := []model.FieldError{}
+	for _, key := range sortedKeys(labels) {
+		for _, message := range validation.IsQualifiedName(key) {
+			fieldErrors = append(fieldErrors, model.FieldError{Field: field, Message: fmt.Sprintf("key %q: %s", key, message)})
+		}
+
+		for _, message := range validation.IsValidLabelValue(labels[key]) {
+			fieldErrors = append(fieldErrors, model.FieldError{Field: field, Message: fmt.Sprintf("value of %q: %s", key, message)})
+		}
+	}
+
+	return fieldErrors
+}
+
+func valueErrors(values map[string][]byte) []model.FieldError {
+	fieldErrors := []model.FieldError{}
+	for _, key := range sortedKeys(values) {
+		for _, message := range validation.IsConfigMapKey(key) {
+			fieldErrors = append(fieldErrors, model.FieldError{Field: "values", Message: fmt.Sprintf("key %q: %s", key, message)})
+		}
+	}
+
+	if err := sizeError(dataSize(values)); err != nil {
+		fieldErrors = append(fieldErrors, *err)
+	}
+
+	return fieldErrors
+}
+
+// dataSize counts the keys and values like the Kubernetes API does.
+func dataSize(values map[string][]byte) int {
+	size := 0
+	for key, value := range values {
+		size += len(key) + len(value)
+	}
+
+	return size
+}
+
+func sizeError(size int) *model.FieldError {
+	if size > maxSecretSize {
+		return &model.FieldError{Field: "values", Message: fmt.Sprintf("the values take %d bytes, more than the 1 MiB limit of a Secret", size)}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package sealedsecret
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSecret(t *testing.T) {
+	tcs := []struct {
+		name       string
+		namespace  string
+		secretName string
+		values     map[string][]byte
+		wantFields []string
+	}{
+		{
+			name:       "valid",
+			namespace:  "payments",
+			secretName: "api.credentials",
+			values:     map[string][]byte{"API_TOKEN": []byte("token"), ".dockerconfigjson": []byte("{}")},
+		},
+		{
+			name:       "invalid names",
+			namespace:  "Payments",
+			secretName: "api_credentials",
+			values:     map[string][]byte{"API_TOKEN": []byte("token")},
+			wantFields: []string{"namespace", "secretName"},
+		},
+		{
+			name:       "invalid keys",
+			namespace:  "payments",
+			secretName: "api",
+			values:     map[string][]byte{"api token": nil, "path/key": nil},
+			wantFields: []string{"values", "values"},
+		},
+		{
+			name:       "too large",
+			namespace:  "payments",
+			secretName: "api",
+			values:     map[string][]byte{"a": []byte(strings.Repeat("a", maxSecretSize))},
+			wantFields: []string{"values"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateSecret(tc.namespace, tc.secretName, tc.values)
+			if len(tc.wantFields) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr model.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+
+			fields := []string{}
+			for _, fieldError := range validationErr.Errors {
+				fields = append(fields, fieldError.Field)
+			}
+			assert.Equal(t, tc.wantFields, fields)
+		})
+	}
+}
+
+func TestValidateMetadata(t *testing.T) {
+	tcs := []struct {
+		name       string
+		opts       model.CreateOpts
+		wantFields []string
+	}{
+		{
+			name: "valid",
+			opts: model.CreateOpts{
+				Labels:             map[string]string{"app.kubernetes.io/name": "api", "team": ""},
+				SealedSecretLabels: map[string]string{"team": "payments"},
+				TemplateData:       map[string]string{"DATABASE_URL": "{{ .PG_URL }}", ".env": ""},
+			},
+		},
+		{
+			name: "invalid label keys and values",
+			opts: model.CreateOpts{
+				Labels:             map[string]string{"app name": "api"},
+				SealedSecretLabels: map[string]string{"team": "payments/billing"},
+			},
+			wantFields: []string{"labels", "sealedSecretLabels"},
+		},
+		{
+			name:       "invalid template data key",
+			opts:       model.CreateOpts{TemplateData: map[string]string{"database url": "{{ .PG_URL }}"}},
+			wantFields: []string{"templateData"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateMetadata(tc.opts)
+			if len(tc.wantFields) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr model.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+
+			fields := []string{}
+			for _, fieldError := range validationErr.Errors {
+				fields = append(fields, fieldError.Field)
+			}
+			assert.Equal(t, tc.wantFields, fields)
+		})
+	}
+}
+
+func TestValidateSecretSizeCountsKeptData(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	kept := strings.Repeat("a", maxSecretSize/2)
+	ciphertext, err := hybridEncrypt(&key.PublicKey, []byte(kept), "")
+	require.NoError(t, err)
+	assert.Equal(t, len(kept), sealedValueSize(ciphertext))
+
+	values := map[string][]byte{"b": []byte(strings.Repeat("b", maxSecretSize/2))}
+	assert.NoError(t, validateSecretSize(values, nil))
+
+	var validationErr model.ValidationError
+	require.ErrorAs(t, validateSecretSize(values, map[string]string{"a": ciphertext}), &validationErr)
+	assert.Equal(t, "values", validationErr.Errors[0].Field)
+}
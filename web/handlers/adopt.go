@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/atom363/sealed-secrets-ui/web/ui"
+	"github.com/rs/zerolog/log"
+)
+
+func (s SealedSecretHandler) AdoptSecretHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	adoptOpts := model.AdoptOpts{
+		Scope:              r.FormValue("scope"),
+		Namespace:          r.FormValue("namespace"),
+		SecretName:         r.FormValue("secretName"),
+		MarkManaged:        r.FormValue("markManaged") == "true",
+		ConfirmScopeChange: r.FormValue("confirmScopeChange") == "true",
+	}
+
+	if fieldErrors := requiredFieldErrors(r, "scope", "namespace", "secretName"); len(fieldErrors) > 0 {
+		respondValidationError(w, r, model.ValidationError{Errors: fieldErrors}, adoptFields)
+		return
+	}
+
+	log.Info().Str("scope", adoptOpts.Scope).Str("namespace", adoptOpts.Namespace).Str("secretName", adoptOpts.SecretName).Bool("markManaged", adoptOpts.MarkManaged).Msg("adopting secret")
+
+	result, err := s.svc.AdoptSecret(r.Context(), adoptOpts)
+	if err != nil {
+		log.Ctx(r.Context()).Err(err).Msg("error adopting secret")
+		if !respondScopeChangeError(w, err) && !respondValidationError(w, r, err, adoptFields) && !respondLintError(w, err) {
+			respondError(w, "Error adopting secret: "+err.Error())
+		}
+		return
+	}
+
+	err = ui.CodeArea(result).Render(r.Context(), w)
+	if err != nil {
+		log.Err(err).Msg("error rendering code area")
+		http.Error(w, "Error rendering code area", http.StatusInternalServerError)
+		return
+	}
+	renderFieldErrors(w, r, adoptFields, nil)
+}
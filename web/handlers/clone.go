@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/atom363/sealed-secrets-ui/web/ui"
+	"github.com/rs/zerolog/log"
+)
+
+func (s SealedSecretHandler) CloneSecretHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	cloneOpts := model.CloneOpts{
+		SourceNamespace:    r.FormValue("sourceNamespace"),
+		SourceSecretName:   r.FormValue("sourceSecretName"),
+		Scope:              r.FormValue("scope"),
+		Namespace:          r.FormValue("namespace"),
+		SecretName:         r.FormValue("secretName"),
+		Keys:               parseKeyList(r.FormValue("keys")),
+		CopyTemplate:       r.FormValue("copyTemplate") == "true",
+		ConfirmScopeChange: r.FormValue("confirmScopeChange") == "true",
+	}
+
+	fieldErrors := requiredFieldErrors(r, "sourceNamespace", "sourceSecretName", "scope", "namespace", "secretName")
+	cloneOpts.Renames, err = parseLabels(r.FormValue("renames"))
+	if err != nil {
+		fieldErrors = append(fieldErrors, model.FieldError{Field: "renames", Message: fmt.Sprintf("Wrongly formatted rename(s): %v", err.Error())})
+	}
+
+	if len(fieldErrors) > 0 {
+		respondValidationError(w, r, model.ValidationError{Errors: fieldErrors}, cloneFields)
+		return
+	}
+
+	log.Info().
+		Str("source", cloneOpts.SourceNamespace+"/"+cloneOpts.SourceSecretName).
+		Str("target", cloneOpts.Namespace+"/"+cloneOpts.SecretName).
+		Str("scope", cloneOpts.Scope).
+		Msg("cloning secret")
+
+	result, err := s.svc.CloneSecret(r.Context(), cloneOpts)
+	if err != nil {
+		log.Ctx(r.Context()).Err(err).Msg("error cloning secret")
+		if !respondScopeChangeError(w, err) && !respondValidationError(w, r, err, cloneFields) && !respondLintError(w, err) {
+			respondError(w, "Error cloning secret: "+err.Error())
+		}
+		return
+	}
+
+	err = ui.CodeArea(result).Render(r.Context(), w)
+	if err != nil {
+		log.Err(err).Msg("error rendering code area")
+		http.Error(w, "Error rendering code area", http.StatusInternalServerError)
+		return
+	}
+	renderFieldErrors(w, r, cloneFields, nil)
+}
+
+// parseKeyList parses a comma-separated list of keys.
+func parseKeyList(data string) []string {
+	keys := []string{}
+	for _, key := range strings.Split(data, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/atom363/sealed-secrets-ui/web/ui"
+	"github.com/rs/zerolog/log"
+)
+
+func (s SealedSecretHandler) MergeSealedSecretsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	mergeOpts := model.MergeOpts{
+		Base:   r.FormValue("base"),
+		Ours:   r.FormValue("ours"),
+		Theirs: r.FormValue("theirs"),
+	}
+
+	if mergeOpts.Ours == "" || mergeOpts.Theirs == "" {
+		respondError(w, "Ours and theirs manifests are required")
+		return
+	}
+
+	result, err := s.svc.MergeSealedSecrets(r.Context(), mergeOpts)
+	if err != nil {
+		log.Ctx(r.Context()).Err(err).Msg("error merging sealed secrets")
+		respondError(w, "Error merging sealed secrets: "+err.Error())
+		return
+	}
+
+	log.Info().Int("conflicts", len(result.Conflicts)).Msg("sealed-secrets merged")
+
+	err = ui.MergeResult(result).Render(r.Context(), w)
+	if err != nil {
+		log.Err(err).Msg("error rendering merge result")
+		http.Error(w, "Error rendering merge result", http.StatusInternalServerError)
+		return
+	}
+}
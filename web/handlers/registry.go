@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/atom363/sealed-secrets-ui/web/ui"
+	"github.com/rs/zerolog/log"
+)
+
+func (s SealedSecretHandler) RegistrySecretHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	registryOpts := model.RegistryOpts{
+		Scope:              r.FormValue("scope"),
+		Namespace:          r.FormValue("namespace"),
+		SecretName:         r.FormValue("secretName"),
+		Registries:         parseRegistries(r.Form),
+		ConfirmScopeChange: r.FormValue("confirmScopeChange") == "true",
+	}
+
+	if fieldErrors := requiredFieldErrors(r, "scope", "namespace", "secretName"); len(fieldErrors) > 0 {
+		respondValidationError(w, r, model.ValidationError{Errors: fieldErrors}, registryFields)
+		return
+	}
+
+	log.Info().Str("scope", registryOpts.Scope).Str("namespace", registryOpts.Namespace).Str("secretName", registryOpts.SecretName).Int("registries", len(registryOpts.Registries)).Msg("building registry secret")
+
+	result, err := s.svc.BuildRegistrySecret(r.Context(), registryOpts)
+	if err != nil {
+		log.Ctx(r.Context()).Err(err).Msg("error building registry secret")
+		if !respondScopeChangeError(w, err) && !respondValidationError(w, r, err, registryFields) && !respondLintError(w, err) {
+			respondError(w, "Error building registry secret: "+err.Error())
+		}
+		return
+	}
+
+	err = ui.CodeArea(result).Render(r.Context(), w)
+	if err != nil {
+		log.Err(err).Msg("error rendering code area")
+		http.Error(w, "Error rendering code area", http.StatusInternalServerError)
+		return
+	}
+	renderFieldErrors(w, r, registryFields, nil)
+}
+
+// RegistryEntryHandler renders an empty row of the registry credentials.
+func (s SealedSecretHandler) RegistryEntryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := ui.RegistryEntry().Render(r.Context(), w)
+	if err != nil {
+		log.Err(err).Msg("error rendering registry entry")
+		http.Error(w, "Error rendering registry entry", http.StatusInternalServerError)
+		return
+	}
+}
+
+// parseRegistries reads the rows of the registry credentials, empty rows are
+// skipped. Missing fields are reported by the service.
+func parseRegistries(form url.Values) []model.RegistryCredential {
+	servers, usernames, passwords, emails := form["registryServer"], form["registryUsername"], form["registryPassword"], form["registryEmail"]
+
+	registries := []model.RegistryCredential{}
+	for i, server := range servers {
+		registry := model.RegistryCredential{
+			Server:   strings.TrimSpace(server),
+			Username: strings.TrimSpace(formValueAt(usernames, i)),
+			Password: formValueAt(passwords, i),
+			Email:    strings.TrimSpace(formValueAt(emails, i)),
+		}
+
+		if registry == (model.RegistryCredential{}) {
+			continue
+		}
+		registries = append(registries, registry)
+	}
+
+	return registries
+}
+
+func formValueAt(values []string, i int) string {
+	if i < len(values) {
+		return values[i]
+	}
+
+	return ""
+}
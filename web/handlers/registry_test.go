@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRegistries(t *testing.T) {
+	got := parseRegistries(url.Values{
+		"registryServer":   {" ghcr.io ", "", "quay.io"},
+		"registryUsername": {"bot", "", "robot"},
+		"registryPassword": {" s3cret ", "", ""},
+		"registryEmail":    {"", "", "robot@example.com"},
+	})
+
+	assert.Equal(t, []model.RegistryCredential{
+		{Server: "ghcr.io", Username: "bot", Password: " s3cret "},
+		{Server: "quay.io", Username: "robot", Email: "robot@example.com"},
+	}, got)
+}
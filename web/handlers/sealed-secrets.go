@@ -5,7 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"html"
+	"maps"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/atom363/sealed-secrets-ui/model"
@@ -15,10 +18,31 @@ import (
 
 var escapedBacktick = strings.Join([]string{`\`, "`"}, "")
 
+// createFields are the fields of the sealing form that show their errors next
+// to them, adoptFields, cloneFields and registryFields those of the other
+// forms.
+var (
+	createFields = []string{
+		"scope", "mode", "namespace", "secretName", "manifest", "certificate", "values", "entries", "generators",
+		"files", "base64Keys", "type", "labels", "sealedSecretLabels", "templateData", "immutable", "behaviour", "output",
+	}
+	adoptFields    = []string{"scope", "namespace", "secretName"}
+	cloneFields    = []string{"sourceNamespace", "sourceSecretName", "scope", "namespace", "secretName", "keys", "renames"}
+	registryFields = []string{"scope", "namespace", "secretName", "registries"}
+)
+
 type sealer interface {
-	CreateSealedSecret(context.Context, model.CreateOpts) (string, error)
+	CreateSealedSecret(context.Context, model.CreateOpts) (model.CreateResult, error)
+	AdoptSecret(context.Context, model.AdoptOpts) (model.CreateResult, error)
+	CloneSecret(context.Context, model.CloneOpts) (model.CreateResult, error)
+	MergeSealedSecrets(context.Context, model.MergeOpts) (model.MergeResult, error)
 	ListNamespaces(context.Context) ([]string, error)
 	ListSecretNames(context.Context, string) ([]string, error)
+	ResolvePreservedMetadata(context.Context, string, string) (model.PreservedMetadata, error)
+	GetExistingScope(context.Context, string, string) (string, error)
+	GetSecretDetails(context.Context, string, string) (model.SecretDetails, error)
+	PreviewSealedSecret(context.Context, model.CreateOpts) (model.PreviewResult, error)
+	BuildRegistrySecret(context.Context, model.RegistryOpts) (model.CreateResult, error)
 }
 
 type SealedSecretHandler struct {
@@ -29,6 +53,115 @@ func NewSealedSecretHandler(svc sealer) SealedSecretHandler {
 	return SealedSecretHandler{svc: svc}
 }
 
+// respondScopeChangeError explains the impact of a scope change that was not
+// confirmed. It reports whether err was such an error.
+func respondScopeChangeError(w http.ResponseWriter, err error) bool {
+	var scopeChangeErr model.ScopeChangeError
+	if !errors.As(err, &scopeChangeErr) {
+		return false
+	}
+
+	impact := "can break the consumers that rely on the current scope"
+	if scopeRank(scopeChangeErr.To) > scopeRank(scopeChangeErr.From) {
+		impact = "weakens the protection of the sealed values"
+	}
+
+	respondError(w, fmt.Sprintf(
+		"The existing SealedSecret was sealed with the %s scope. Sealing it with the %s scope %s. Tick \"Confirm scope change\" and submit again to continue.",
+		scopeChangeErr.From, scopeChangeErr.To, impact,
+	))
+
+	return true
+}
+
+// respondLintError lists the lint issues of the values. It reports whether
+// err was a lint error.
+func respondLintError(w http.ResponseWriter, err error) bool {
+	var lintErr model.LintError
+	if !errors.As(err, &lintErr) {
+		return false
+	}
+
+	w.Header().Set("HX-Retarget", ".message")
+	if err := ui.LintError(lintErr).Render(context.Background(), w); err != nil {
+		log.Err(err).Msg("error rendering lint error")
+		http.Error(w, "Error rendering lint error", http.StatusInternalServerError)
+	}
+
+	return true
+}
+
+// respondValidationError shows the errors of the given fields next to them
+// and the errors of other fields in the message box. It reports whether err
+// was a validation error.
+func respondValidationError(w http.ResponseWriter, r *http.Request, err error, fields []string) bool {
+	var validationErr model.ValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+
+	fieldErrors := make(map[string][]string)
+	otherErrors := []string{}
+	for _, fieldError := range validationErr.Errors {
+		if slices.Contains(fields, fieldError.Field) {
+			fieldErrors[fieldError.Field] = append(fieldErrors[fieldError.Field], fieldError.Message)
+			continue
+		}
+		otherErrors = append(otherErrors, fieldError.Message)
+	}
+
+	message := "Some fields are not valid, see the errors next to them."
+	if len(otherErrors) > 0 {
+		message = "Some fields are not valid: " + strings.Join(otherErrors, "; ")
+	}
+
+	respondError(w, message)
+	renderFieldErrors(w, r, fields, fieldErrors)
+
+	return true
+}
+
+// fieldNames name the required fields in their error messages.
+var fieldNames = map[string]string{
+	"scope":            "scope",
+	"namespace":        "namespace",
+	"secretName":       "secret name",
+	"sourceNamespace":  "source namespace",
+	"sourceSecretName": "source secret name",
+}
+
+// requiredFieldErrors reports the given fields of the form that are empty.
+func requiredFieldErrors(r *http.Request, fields ...string) []model.FieldError {
+	fieldErrors := []model.FieldError{}
+	for _, field := range fields {
+		if r.FormValue(field) == "" {
+			fieldErrors = append(fieldErrors, model.FieldError{Field: field, Message: fmt.Sprintf("The %s is required", fieldNames[field])})
+		}
+	}
+
+	return fieldErrors
+}
+
+// renderFieldErrors swaps the errors next to the fields, an empty map clears
+// them.
+func renderFieldErrors(w http.ResponseWriter, r *http.Request, fields []string, fieldErrors map[string][]string) {
+	err := ui.FieldErrors(fields, fieldErrors).Render(r.Context(), w)
+	if err != nil {
+		log.Err(err).Msg("error rendering field errors")
+	}
+}
+
+func scopeRank(scope string) int {
+	switch scope {
+	case "cluster":
+		return 2
+	case "namespace":
+		return 1
+	default:
+		return 0
+	}
+}
+
 func respondError(w http.ResponseWriter, message string) {
 	w.Header().Set("HX-Retarget", ".message")
 
@@ -76,88 +209,437 @@ func (s SealedSecretHandler) SecretOptionsHandler(w http.ResponseWriter, r *http
 	}
 
 	namespace := r.URL.Query().Get("namespace")
+	datalistID := "secret-options"
+	if sourceNamespace := r.URL.Query().Get("sourceNamespace"); sourceNamespace != "" {
+		namespace = sourceNamespace
+		datalistID = "source-secret-options"
+	}
+
 	secrets, err := s.svc.ListSecretNames(r.Context(), namespace)
 	if err != nil {
 		log.Ctx(r.Context()).Err(err).Msg("error listing secrets")
 		secrets = []string{}
 	}
 
-	renderDatalist(w, "secret-options", secrets)
+	renderDatalist(w, datalistID, secrets)
 }
 
-func (s SealedSecretHandler) CreateSealedSecretHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+func (s SealedSecretHandler) ScopeFieldHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	err := r.ParseForm()
+	scope, err := s.svc.GetExistingScope(r.Context(), r.URL.Query().Get("namespace"), r.URL.Query().Get("secretName"))
 	if err != nil {
-		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		log.Ctx(r.Context()).Err(err).Msg("error getting existing scope")
+	}
+
+	// keep the current selection when there is nothing to preselect
+	if scope == "" {
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
-	scope := r.FormValue("scope")
-	namespace := r.FormValue("namespace")
-	secretName := r.FormValue("secretName")
-	valuesToEncrypt := r.FormValue("values")
 
-	if scope == "" || namespace == "" || secretName == "" || valuesToEncrypt == "" {
-		respondError(w, "All fields are required")
+	err = ui.ScopeField(scope).Render(r.Context(), w)
+	if err != nil {
+		log.Err(err).Msg("error rendering scope field")
+		http.Error(w, "Error rendering scope field", http.StatusInternalServerError)
 		return
 	}
+}
+
+func (s SealedSecretHandler) SecretDetailsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+	secretName := r.URL.Query().Get("secretName")
+	details, err := s.svc.GetSecretDetails(r.Context(), namespace, secretName)
+	if err != nil {
+		log.Ctx(r.Context()).Err(err).Msg("error getting secret details")
+		details.Warnings = append(details.Warnings, "The existing secret could not be read")
+	}
 
-	log.Info().Str("scope", scope).Str("namespace", namespace).Str("secretName", secretName).Msg("creating sealed secret")
-	keyValues, err := parseKeyValuePairs(valuesToEncrypt)
+	err = ui.SecretDetails(details).Render(r.Context(), w)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Wrongly formatted value(s): %v", err.Error()))
+		log.Err(err).Msg("error rendering secret details")
+		http.Error(w, "Error rendering secret details", http.StatusInternalServerError)
 		return
 	}
+}
 
-	if keyValues == nil {
-		respondError(w, "No key-value pairs found")
+func (s SealedSecretHandler) PreservedMetadataHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	createOpts := model.CreateOpts{
-		Scope:      scope,
-		Namespace:  namespace,
-		SecretName: secretName,
-		Values:     keyValues,
+	namespace := r.URL.Query().Get("namespace")
+	secretName := r.URL.Query().Get("secretName")
+	preserved, err := s.svc.ResolvePreservedMetadata(r.Context(), namespace, secretName)
+	if err != nil {
+		log.Ctx(r.Context()).Err(err).Msg("error resolving preserved metadata")
 	}
 
-	yamlManifest, err := s.svc.CreateSealedSecret(r.Context(), createOpts)
+	err = ui.PreservedMetadata(preserved).Render(r.Context(), w)
+	if err != nil {
+		log.Err(err).Msg("error rendering preserved metadata")
+		http.Error(w, "Error rendering preserved metadata", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s SealedSecretHandler) CreateSealedSecretHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := parseForm(w, r)
+	if err != nil {
+		respondError(w, fmt.Sprintf("Error parsing form: %v", err))
+		return
+	}
+
+	createOpts, err := parseCreateOpts(r)
+	if err != nil {
+		if !respondValidationError(w, r, err, createFields) {
+			respondError(w, err.Error())
+		}
+		return
+	}
+
+	log.Info().Str("scope", createOpts.Scope).Str("mode", createOpts.Mode).Str("output", createOpts.Output).Str("namespace", createOpts.Namespace).Str("secretName", createOpts.SecretName).Msg("creating sealed secret")
+	result, err := s.svc.CreateSealedSecret(r.Context(), createOpts)
 
-	log.Info().Str("yaml", yamlManifest).Msg("sealed-secret created")
+	log.Info().Str("yaml", result.Manifest).Strs("droppedKeys", result.DroppedKeys).Msg("sealed-secret created")
 
 	if err != nil {
 		log.Ctx(r.Context()).Err(err).Msg("error creating sealed secret")
-		respondError(w, "Error creating sealed secret")
+		if !respondScopeChangeError(w, err) && !respondValidationError(w, r, err, createFields) && !respondLintError(w, err) {
+			respondError(w, "Error creating sealed secret")
+		}
 		return
 	}
 
-	err = ui.CodeArea(yamlManifest).Render(r.Context(), w)
+	err = ui.CodeArea(result).Render(r.Context(), w)
 	if err != nil {
 		log.Err(err).Msg("error rendering code area")
 		http.Error(w, "Error rendering code area", http.StatusInternalServerError)
 		return
 	}
+	renderFieldErrors(w, r, createFields, nil)
 }
 
-func parseKeyValuePairs(data string) (map[string]string, error) {
-	result := make(map[string]string)
-	lines := strings.Split(data, "\n")
+func (s SealedSecretHandler) PreviewSealedSecretHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := parseForm(w, r)
+	if err != nil {
+		respondError(w, fmt.Sprintf("Error parsing form: %v", err))
+		return
+	}
+
+	createOpts, err := parseCreateOpts(r)
+	if err != nil {
+		if !respondValidationError(w, r, err, createFields) {
+			respondError(w, err.Error())
+		}
+		return
+	}
+
+	result, err := s.svc.PreviewSealedSecret(r.Context(), createOpts)
+	if err != nil {
+		log.Ctx(r.Context()).Err(err).Msg("error previewing sealed secret")
+		if !respondValidationError(w, r, err, createFields) {
+			respondError(w, "Error previewing sealed secret: "+err.Error())
+		}
+		return
+	}
+
+	err = ui.Preview(result).Render(r.Context(), w)
+	if err != nil {
+		log.Err(err).Msg("error rendering preview")
+		http.Error(w, "Error rendering preview", http.StatusInternalServerError)
+		return
+	}
+	renderFieldErrors(w, r, createFields, nil)
+}
+
+func (s SealedSecretHandler) ValueEntryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := ui.ValueEntry().Render(r.Context(), w)
+	if err != nil {
+		log.Err(err).Msg("error rendering value entry")
+		http.Error(w, "Error rendering value entry", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GeneratorEntryHandler renders an empty row of the generators.
+func (s SealedSecretHandler) GeneratorEntryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := ui.GeneratorEntry().Render(r.Context(), w)
+	if err != nil {
+		log.Err(err).Msg("error rendering generator entry")
+		http.Error(w, "Error rendering generator entry", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ValidateKeyHandler validates the key of a single row of the structured
+// editor.
+func (s SealedSecretHandler) ValidateKeyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// the value rows and the generators send their key under their own name
+	query := r.URL.Query()
+	key := query.Get("entryKey")
+	if key == "" {
+		key = query.Get("generatorKey")
+	}
+
+	var message string
+	if key != "" {
+		if err := validateKey(key); err != nil {
+			message = err.Error()
+		}
+	}
+
+	err := ui.KeyError(message).Render(r.Context(), w)
+	if err != nil {
+		log.Err(err).Msg("error rendering key error")
+		http.Error(w, "Error rendering key error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ParsedValuesHandler previews the keys of the values to seal, never their
+// values.
+func (s SealedSecretHandler) ParsedValuesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := parseForm(w, r)
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	var parsed parsedValues
+	var parseErr string
+	if values := r.FormValue("values"); strings.TrimSpace(values) != "" {
+		parsed, err = parseValues(values, r.FormValue("valuesFormat"))
+		if err != nil {
+			parseErr = err.Error()
+		}
+	}
+
+	err = ui.ParsedValues(parsed.format, slices.Sorted(maps.Keys(parsed.values)), parsed.namespace, parsed.secretName, parseErr).Render(r.Context(), w)
+	if err != nil {
+		log.Err(err).Msg("error rendering parsed values")
+		http.Error(w, "Error rendering parsed values", http.StatusInternalServerError)
+		return
+	}
+}
+
+// parseCreateOpts reads the sealing form. The returned errors are meant to be
+// shown to the user, next to the field they belong to.
+func parseCreateOpts(r *http.Request) (model.CreateOpts, error) {
+	scope := r.FormValue("scope")
+	mode := r.FormValue("mode")
+	output := r.FormValue("output")
+	namespace := r.FormValue("namespace")
+	secretName := r.FormValue("secretName")
+	valuesToEncrypt := r.FormValue("values")
+	manifest := strings.TrimSpace(r.FormValue("manifest"))
+
+	fieldErrors := []model.FieldError{}
+	values := make(map[string][]byte)
+	var parsed parsedValues
+	if strings.TrimSpace(valuesToEncrypt) != "" {
+		var err error
+		parsed, err = parseValues(valuesToEncrypt, r.FormValue("valuesFormat"))
+		if err != nil {
+			fieldErrors = append(fieldErrors, model.FieldError{Field: "values", Message: fmt.Sprintf("Wrongly formatted value(s): %v", err.Error())})
+		}
+
+		for key, value := range parsed.values {
+			values[key] = []byte(value)
+		}
+	}
 
-	if len(lines) == 1 && len(lines[0]) == 0 {
+	entryValues, err := parseEntries(r.Form["entryKey"], r.Form["entryValue"])
+	if err != nil {
+		fieldErrors = append(fieldErrors, model.FieldError{Field: "entries", Message: fmt.Sprintf("Wrongly formatted value row(s): %v", err.Error())})
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(entryValues)) {
+		if _, ok := values[key]; ok {
+			fieldErrors = append(fieldErrors, model.FieldError{Field: "entries", Message: fmt.Sprintf("Key %s is given in the values and as a row", key)})
+			continue
+		}
+		values[key] = entryValues[key]
+	}
+
+	err = decodeBase64Values(values, parseKeyList(r.FormValue("base64Keys")))
+	if err != nil {
+		fieldErrors = append(fieldErrors, model.FieldError{Field: "base64Keys", Message: fmt.Sprintf("Wrongly encoded value(s): %v", err.Error())})
+	}
+
+	fileValues, err := readFileValues(r.MultipartForm)
+	if err != nil {
+		fieldErrors = append(fieldErrors, model.FieldError{Field: "files", Message: fmt.Sprintf("Wrong file(s): %v", err.Error())})
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(fileValues)) {
+		if _, ok := values[key]; ok {
+			fieldErrors = append(fieldErrors, model.FieldError{Field: "files", Message: fmt.Sprintf("Key %s is given as a value and as a file", key)})
+			continue
+		}
+		values[key] = fileValues[key]
+	}
+
+	generators, err := parseGenerators(r.Form)
+	if err != nil {
+		fieldErrors = append(fieldErrors, model.FieldError{Field: "generators", Message: fmt.Sprintf("Wrong generator(s): %v", err.Error())})
+	}
+
+	// an imported Secret manifest names the secret to seal
+	if namespace == "" {
+		namespace = parsed.namespace
+	}
+
+	if secretName == "" {
+		secretName = parsed.secretName
+	}
+
+	if len(fieldErrors) == 0 && len(values) == 0 && len(generators) == 0 {
+		fieldErrors = append(fieldErrors, model.FieldError{Field: "values", Message: "No key-value pairs found"})
+	}
+
+	// a pasted SealedSecret manifest already carries the scope, namespace and name
+	if manifest == "" {
+		if scope == "" {
+			fieldErrors = append(fieldErrors, model.FieldError{Field: "scope", Message: "The scope is required"})
+		}
+
+		if namespace == "" {
+			fieldErrors = append(fieldErrors, model.FieldError{Field: "namespace", Message: "The namespace is required"})
+		}
+
+		if secretName == "" {
+			fieldErrors = append(fieldErrors, model.FieldError{Field: "secretName", Message: "The secret name is required"})
+		}
+	}
+
+	if mode == "" {
+		mode = "merge"
+	}
+
+	if mode != "merge" && mode != "merge-sealed" && mode != "replace" {
+		fieldErrors = append(fieldErrors, model.FieldError{Field: "mode", Message: fmt.Sprintf("Unknown mode: %s", mode)})
+	}
+
+	if output == "" {
+		output = "manifest"
+	}
+
+	if output != "manifest" && output != "patch" {
+		fieldErrors = append(fieldErrors, model.FieldError{Field: "output", Message: fmt.Sprintf("Unknown output: %s", output)})
+	}
+
+	labels, err := parseLabels(r.FormValue("labels"))
+	if err != nil {
+		fieldErrors = append(fieldErrors, model.FieldError{Field: "labels", Message: fmt.Sprintf("Wrongly formatted label(s): %v", err.Error())})
+	}
+
+	sealedSecretLabels, err := parseLabels(r.FormValue("sealedSecretLabels"))
+	if err != nil {
+		fieldErrors = append(fieldErrors, model.FieldError{Field: "sealedSecretLabels", Message: fmt.Sprintf("Wrongly formatted SealedSecret label(s): %v", err.Error())})
+	}
+
+	var templateData map[string]string
+	if rawTemplateData := r.FormValue("templateData"); strings.TrimSpace(rawTemplateData) != "" {
+		templateData, err = parseKeyValuePairs(rawTemplateData)
+		if err != nil {
+			fieldErrors = append(fieldErrors, model.FieldError{Field: "templateData", Message: fmt.Sprintf("Wrongly formatted template data: %v", err.Error())})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return model.CreateOpts{}, model.ValidationError{Errors: fieldErrors}
+	}
+
+	return model.CreateOpts{
+		Scope:                  scope,
+		Mode:                   mode,
+		Namespace:              namespace,
+		SecretName:             secretName,
+		Values:                 values,
+		Type:                   r.FormValue("type"),
+		Labels:                 labels,
+		Immutable:              r.FormValue("immutable") == "true",
+		SealedSecretLabels:     sealedSecretLabels,
+		TemplateData:           templateData,
+		Managed:                r.FormValue("managed") == "true",
+		Patch:                  r.FormValue("patch") == "true",
+		SkipSetOwnerReferences: r.FormValue("skipSetOwnerReferences") == "true",
+		ReplaceTemplate:        r.FormValue("replaceTemplate") == "true",
+		ConfirmScopeChange:     r.FormValue("confirmScopeChange") == "true",
+		AcknowledgeWarnings:    r.FormValue("acknowledgeWarnings") == "true",
+		Generators:             generators,
+		Manifest:               manifest,
+		Certificate:            strings.TrimSpace(r.FormValue("certificate")),
+		Output:                 output,
+	}, nil
+}
+
+// parseKeyValuePairs parses the values in a dotenv like syntax. Blank lines
+// and lines starting with # are skipped and keys may have an export prefix.
+// Values are trimmed unless they are quoted: single-quoted values are taken
+// literally, double-quoted ones support the escape sequences of Go strings.
+// A # preceded by whitespace starts a comment after the value, otherwise it
+// is part of the value.
+// A value starting with a backtick spans multiple lines until the line ending
+// with a backtick, backticks inside it are escaped with a backslash.
+func parseKeyValuePairs(data string) (map[string]string, error) {
+	if strings.TrimSpace(data) == "" {
 		return nil, errors.New("empty")
 	}
 
+	result := make(map[string]string)
+	lines := strings.Split(data, "\n")
+
 	var multilineKey string
+	var multilineLine, multilineColumn int
 	var multilineValue strings.Builder
 
 	for i, line := range lines {
+		lineNumber := i + 1
 
 		// inside backticked block
 		if len(multilineKey) > 0 {
+			line = strings.TrimSuffix(line, "\r")
 			var isEndOfBlock bool
 			if !strings.HasSuffix(line, escapedBacktick) {
 				line, isEndOfBlock = strings.CutSuffix(line, "`")
@@ -173,16 +655,38 @@ func parseKeyValuePairs(data string) (map[string]string, error) {
 			continue
 		}
 
-		parts := strings.SplitN(line, "=", 2)
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		keyColumn := strings.Index(line, trimmed) + 1
+		rawKey, rawValue, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d, column %d: missing '='", lineNumber, keyColumn)
+		}
+
+		key := strings.TrimSpace(rawKey)
+		if exported, ok := strings.CutPrefix(key, "export"); ok && exported != strings.TrimLeft(exported, " \t") {
+			key = strings.TrimSpace(exported)
+		}
+
+		if key == "" {
+			return nil, fmt.Errorf("line %d, column %d: empty key", lineNumber, keyColumn)
+		}
 
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("Missing '=' at line: %v", i)
+		if _, ok := result[key]; ok {
+			return nil, fmt.Errorf("line %d, column %d: duplicate key %q", lineNumber, keyColumn, key)
 		}
 
+		value := strings.TrimSpace(rawValue)
+		valueColumn := keyColumn + len(rawKey) + 1 + strings.Index(rawValue, value)
+
 		// backticked block starts
-		part, ok := strings.CutPrefix(parts[1], "`")
+		part, ok := strings.CutPrefix(value, "`")
 		if ok {
-			multilineKey = parts[0]
+			multilineKey = key
+			multilineLine, multilineColumn = lineNumber, valueColumn
 
 			var isEndOfBlock bool
 			if !strings.HasSuffix(part, escapedBacktick) {
@@ -198,12 +702,96 @@ func parseKeyValuePairs(data string) (map[string]string, error) {
 			continue
 		}
 
-		// oneline value
-		result[parts[0]] = parts[1]
+		value, err := unquoteValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d, column %d: %w", lineNumber, valueColumn, err)
+		}
+
+		result[key] = value
 	}
 
 	if len(multilineKey) != 0 {
-		return nil, fmt.Errorf("Backticked block is not closed")
+		return nil, fmt.Errorf("line %d, column %d: backticked block is not closed", multilineLine, multilineColumn)
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	}
+
+	return result, nil
+}
+
+// unquoteValue removes the quotes of a single- or double-quoted value. A
+// comment may follow the closing quote or the unquoted value.
+func unquoteValue(value string) (string, error) {
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		return stripComment(value), nil
+	}
+
+	quote := value[0]
+	end := -1
+	for i := 1; i < len(value); i++ {
+		if quote == '"' && value[i] == '\\' {
+			i++
+			continue
+		}
+		if value[i] == quote {
+			end = i
+			break
+		}
+	}
+
+	if end == -1 {
+		return "", fmt.Errorf("quote %c is not closed", quote)
+	}
+
+	if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected %q after the closing quote", rest)
+	}
+
+	if quote == '\'' {
+		return value[1:end], nil
+	}
+
+	unquoted, err := strconv.Unquote(value[:end+1])
+	if err != nil {
+		return "", errors.New("invalid escape sequence in double-quoted value")
+	}
+
+	return unquoted, nil
+}
+
+// stripComment removes a comment from an unquoted value.
+func stripComment(value string) string {
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimSpace(value[:i])
+		}
+	}
+
+	return value
+}
+
+// parseLabels parses comma-separated key=value pairs.
+func parseLabels(data string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(data, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%q is not formatted as key=value", pair)
+		}
+
+		result[key] = strings.TrimSpace(value)
+	}
+
+	if len(result) == 0 {
+		return nil, nil
 	}
 
 	return result, nil
@@ -1,8 +1,16 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
+	"github.com/atom363/sealed-secrets-ui/model"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -141,6 +149,76 @@ some-val
 xcvb`,
 			},
 		},
+		{
+			name: "comments and blank lines",
+			field: `# database
+PG_USER=app
+
+  # indented comment
+PG_PASSWORD=secret
+`,
+			want: map[string]string{
+				"PG_USER":     "app",
+				"PG_PASSWORD": "secret",
+			},
+		},
+		{
+			name:  "only comments",
+			field: "# nothing to seal",
+		},
+		{
+			name:  "trims keys and unquoted values",
+			field: "  API_TOKEN =  token  \r\nexport  PG_USER=app\nexporter=value",
+			want: map[string]string{
+				"API_TOKEN": "token",
+				"PG_USER":   "app",
+				"exporter":  "value",
+			},
+		},
+		{
+			name:  "comment after unquoted value",
+			field: "PG_USER=app # the owner\nPG_HOST=db\t# primary\nCOLOR=#fff\nANCHOR=page#top",
+			want: map[string]string{
+				"PG_USER": "app",
+				"PG_HOST": "db",
+				"COLOR":   "#fff",
+				"ANCHOR":  "page#top",
+			},
+		},
+		{
+			name:  "single-quoted value",
+			field: `GREETING='  hello "world" \n ' # comment`,
+			want: map[string]string{
+				"GREETING": `  hello "world" \n `,
+			},
+		},
+		{
+			name:  "double-quoted value with escape sequences",
+			field: `GREETING=" hello\t\"world\"\n" # comment`,
+			want: map[string]string{
+				"GREETING": " hello\t\"world\"\n",
+			},
+		},
+		{
+			name:      "unclosed quote",
+			field:     `GREETING="hello`,
+			isWantErr: true,
+		},
+		{
+			name:      "text after closing quote",
+			field:     `GREETING='hello' world`,
+			isWantErr: true,
+		},
+		{
+			name:      "empty key",
+			field:     "=value",
+			isWantErr: true,
+		},
+		{
+			name:      "duplicate key",
+			field:     "API_TOKEN=a\nAPI_TOKEN=b",
+			isWantErr: true,
+		},
 	}
 
 	for _, tc := range tcs {
@@ -155,3 +233,243 @@ xcvb`,
 		})
 	}
 }
+
+func TestParseLabels(t *testing.T) {
+	tcs := []struct {
+		name      string
+		field     string
+		want      map[string]string
+		isWantErr bool
+	}{
+		{
+			name:  "empty",
+			field: " ",
+		},
+		{
+			name:  "trims pairs",
+			field: " app.kubernetes.io/name = my-app , team=payments,",
+			want: map[string]string{
+				"app.kubernetes.io/name": "my-app",
+				"team":                   "payments",
+			},
+		},
+		{
+			name:      "missing equal sign",
+			field:     "team",
+			isWantErr: true,
+		},
+		{
+			name:      "empty key",
+			field:     "=payments",
+			isWantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, gotErr := parseLabels(tc.field)
+			assert.Equal(t, tc.want, got)
+			if tc.isWantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestParseKeyValuePairsErrorPosition(t *testing.T) {
+	tcs := []struct {
+		name  string
+		field string
+		want  string
+	}{
+		{
+			name:  "missing equal sign",
+			field: "# comment\nAPI_TOKEN=a\n  PG_USER",
+			want:  "line 3, column 3: missing '='",
+		},
+		{
+			name:  "empty key",
+			field: "API_TOKEN=a\n = b",
+			want:  "line 2, column 2: empty key",
+		},
+		{
+			name:  "duplicate key",
+			field: "API_TOKEN=a\nexport API_TOKEN=b",
+			want:  `line 2, column 1: duplicate key "API_TOKEN"`,
+		},
+		{
+			name:  "unclosed quote",
+			field: `API_TOKEN= "a`,
+			want:  "line 1, column 12: quote \" is not closed",
+		},
+		{
+			name:  "unclosed backticked block",
+			field: "API_TOKEN=a\nPRIV_KEY=`--begin\nsome-val",
+			want:  "line 2, column 10: backticked block is not closed",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			_, gotErr := parseKeyValuePairs(tc.field)
+			assert.EqualError(t, gotErr, tc.want)
+		})
+	}
+}
+
+func TestRespondValidationError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/sealed-secret/adopt", nil)
+	err := fmt.Errorf("failed: %w", model.ValidationError{Errors: []model.FieldError{
+		{Field: "namespace", Message: "invalid namespace"},
+		{Field: "values", Message: "too large"},
+	}})
+
+	assert.True(t, respondValidationError(w, r, err, adoptFields))
+	assert.Equal(t, ".message", w.Header().Get("HX-Retarget"))
+
+	body := w.Body.String()
+	assert.Contains(t, body, "Some fields are not valid: too large")
+	assert.Contains(t, body, `<div id="namespace-error" hx-swap-oob="true"><p class="help is-danger">invalid namespace</p></div>`)
+	assert.Contains(t, body, `<div id="secretName-error" hx-swap-oob="true"></div>`)
+
+	assert.False(t, respondValidationError(httptest.NewRecorder(), r, errors.New("other"), adoptFields))
+}
+
+// fakeSealer fails every sealing with err, the other methods are not used.
+type fakeSealer struct {
+	sealer
+	err error
+}
+
+func (f fakeSealer) CreateSealedSecret(context.Context, model.CreateOpts) (model.CreateResult, error) {
+	return model.CreateResult{}, f.err
+}
+
+func TestCreateSealedSecretHandlerShowsValidationErrors(t *testing.T) {
+	tcs := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "secret type",
+			err: model.ValidationError{Errors: []model.FieldError{{
+				Field:   "type",
+				Message: "secret type kubernetes.io/basic-auth requires the key(s) username or password",
+			}}},
+			want: `<div id="type-error" hx-swap-oob="true"><p class="help is-danger">secret type kubernetes.io/basic-auth requires the key(s) username or password</p></div>`,
+		},
+		{
+			name: "template data",
+			err: model.ValidationError{Errors: []model.FieldError{{
+				Field:   "templateData",
+				Message: "template DATABASE_URL references unknown key(s) PG_PASS",
+			}}},
+			want: `<div id="templateData-error" hx-swap-oob="true"><p class="help is-danger">template DATABASE_URL references unknown key(s) PG_PASS</p></div>`,
+		},
+		{
+			name: "behaviour",
+			err:  model.ValidationError{Errors: []model.FieldError{{Field: "behaviour", Message: "an immutable secret cannot be patched"}}},
+			want: `<div id="behaviour-error" hx-swap-oob="true"><p class="help is-danger">an immutable secret cannot be patched</p></div>`,
+		},
+		{
+			name: "other error",
+			err:  errors.New("connection refused"),
+			want: "Error creating sealed secret",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			form := url.Values{
+				"scope":      {"strict"},
+				"namespace":  {"default"},
+				"secretName": {"app"},
+				"values":     {"password=s3cr3t"},
+			}
+			r := httptest.NewRequest(http.MethodPost, "/sealed-secret", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+
+			NewSealedSecretHandler(fakeSealer{err: tc.err}).CreateSealedSecretHandler(w, r)
+
+			assert.Equal(t, ".message", w.Header().Get("HX-Retarget"))
+			assert.Contains(t, w.Body.String(), tc.want)
+		})
+	}
+}
+
+func TestCreateSealedSecretHandlerMarksRequiredFields(t *testing.T) {
+	form := url.Values{"scope": {"strict"}, "namespace": {"default"}}
+	r := httptest.NewRequest(http.MethodPost, "/sealed-secret", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	NewSealedSecretHandler(fakeSealer{}).CreateSealedSecretHandler(w, r)
+
+	body := w.Body.String()
+	assert.Contains(t, body, "Some fields are not valid, see the errors next to them.")
+	assert.Contains(t, body, `<div id="namespace-error" hx-swap-oob="true"></div>`)
+	assert.Contains(t, body, `<div id="secretName-error" hx-swap-oob="true"><p class="help is-danger">The secret name is required</p></div>`)
+	assert.Contains(t, body, `<div id="values-error" hx-swap-oob="true"><p class="help is-danger">No key-value pairs found</p></div>`)
+}
+
+func TestCreateSealedSecretHandlerMarksFormErrors(t *testing.T) {
+	form := url.Values{
+		"scope":        {"strict"},
+		"namespace":    {"default"},
+		"secretName":   {"app"},
+		"values":       {"password=s3cr3t"},
+		"mode":         {"append"},
+		"labels":       {"team"},
+		"templateData": {"=value"},
+	}
+	r := httptest.NewRequest(http.MethodPost, "/sealed-secret", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	NewSealedSecretHandler(fakeSealer{}).CreateSealedSecretHandler(w, r)
+
+	body := w.Body.String()
+	assert.Contains(t, body, "Some fields are not valid, see the errors next to them.")
+	assert.Contains(t, body, `<div id="mode-error" hx-swap-oob="true"><p class="help is-danger">Unknown mode: append</p></div>`)
+	assert.Contains(t, body, `<div id="labels-error" hx-swap-oob="true"><p class="help is-danger">Wrongly formatted label(s): &#34;team&#34; is not formatted as key=value</p></div>`)
+	assert.Contains(t, body, `<div id="templateData-error" hx-swap-oob="true"><p class="help is-danger">Wrongly formatted template data: line 1, column 1: empty key</p></div>`)
+	assert.Contains(t, body, `<div id="values-error" hx-swap-oob="true"></div>`)
+}
+
+func TestAdoptSecretHandlerMarksRequiredFields(t *testing.T) {
+	form := url.Values{"namespace": {"default"}}
+	r := httptest.NewRequest(http.MethodPost, "/sealed-secret/adopt", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	NewSealedSecretHandler(fakeSealer{}).AdoptSecretHandler(w, r)
+
+	body := w.Body.String()
+	assert.Contains(t, body, "Some fields are not valid, see the errors next to them.")
+	assert.Contains(t, body, `<div id="scope-error" hx-swap-oob="true"><p class="help is-danger">The scope is required</p></div>`)
+	assert.Contains(t, body, `<div id="namespace-error" hx-swap-oob="true"></div>`)
+	assert.Contains(t, body, `<div id="secretName-error" hx-swap-oob="true"><p class="help is-danger">The secret name is required</p></div>`)
+}
+
+func TestRespondLintError(t *testing.T) {
+	warnings := model.LintError{Issues: []model.LintIssue{{Key: "API_TOKEN", Rule: "placeholder", Message: "looks like a placeholder"}}}
+	errs := model.LintError{Issues: []model.LintIssue{{Key: "tls.key", Rule: "pem", Message: "is not a complete PEM block", Error: true}}}
+
+	w := httptest.NewRecorder()
+	assert.True(t, respondLintError(w, fmt.Errorf("failed: %w", warnings)))
+	assert.Equal(t, ".message", w.Header().Get("HX-Retarget"))
+	assert.Contains(t, w.Body.String(), "Acknowledge value warnings")
+	assert.Contains(t, w.Body.String(), "<code>API_TOKEN</code> looks like a placeholder")
+
+	w = httptest.NewRecorder()
+	assert.True(t, respondLintError(w, errs))
+	assert.NotContains(t, w.Body.String(), "Acknowledge value warnings")
+	assert.Contains(t, w.Body.String(), "is-danger")
+
+	assert.False(t, respondLintError(httptest.NewRecorder(), errors.New("other")))
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// maxFileSize is the size limit of a Secret, so no single file can be
+	// larger.
+	maxFileSize = 1 << 20
+	// maxRequestSize limits the whole form including the uploaded files.
+	maxRequestSize = 4 << 20
+	// fileFieldPrefix prefixes the key in the name of the file inputs.
+	fileFieldPrefix = "file:"
+)
+
+// parsedValues are the values to seal along with the namespace and name of a
+// Secret manifest they were read from.
+type parsedValues struct {
+	format     string
+	values     map[string]string
+	namespace  string
+	secretName string
+}
+
+type secretManifest struct {
+	Kind       string            `yaml:"kind"`
+	Metadata   model.Metadata    `yaml:"metadata"`
+	Data       map[string]string `yaml:"data"`
+	StringData map[string]string `yaml:"stringData"`
+}
+
+// parseValues parses the values in the given format: "dotenv" for key=value
+// pairs, "secret" for a Secret manifest, "json" for a JSON object or "auto"
+// to detect the format.
+func parseValues(data, format string) (parsedValues, error) {
+	if format == "" || format == "auto" {
+		format = detectValuesFormat(data)
+	}
+
+	result := parsedValues{format: format}
+
+	var err error
+	switch format {
+	case "dotenv":
+		result.values, err = parseKeyValuePairs(data)
+	case "secret":
+		var metadata model.Metadata
+		result.values, metadata, err = parseSecretManifest(data)
+		result.namespace, result.secretName = metadata.Namespace, metadata.Name
+	case "json":
+		result.values, err = parseJSONValues(data)
+	default:
+		err = fmt.Errorf("unknown format %q", format)
+	}
+
+	return result, err
+}
+
+// detectValuesFormat tells a Secret manifest, in YAML or JSON, from a JSON
+// object and key=value pairs.
+func detectValuesFormat(data string) string {
+	var manifest struct {
+		Kind string `yaml:"kind"`
+	}
+	if err := yaml.Unmarshal([]byte(data), &manifest); err == nil && manifest.Kind == "Secret" {
+		return "secret"
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(data), "{") {
+		return "json"
+	}
+
+	return "dotenv"
+}
+
+// parseSecretManifest reads a Secret manifest. The base64 encoded data is
+// decoded and merged into the string data, which takes precedence like it
+// does in Kubernetes.
+func parseSecretManifest(data string) (map[string]string, model.Metadata, error) {
+	var manifest secretManifest
+	if err := yaml.Unmarshal([]byte(data), &manifest); err != nil {
+		return nil, model.Metadata{}, fmt.Errorf("failed to parse secret manifest: %w", err)
+	}
+
+	if manifest.Kind != "Secret" {
+		return nil, model.Metadata{}, fmt.Errorf("expected kind Secret, got %q", manifest.Kind)
+	}
+
+	values := make(map[string]string, len(manifest.Data)+len(manifest.StringData))
+	for key, value := range manifest.Data {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, model.Metadata{}, fmt.Errorf("data.%s is not base64 encoded", key)
+		}
+		values[key] = string(decoded)
+	}
+
+	for key, value := range manifest.StringData {
+		values[key] = value
+	}
+
+	if len(values) == 0 {
+		return nil, model.Metadata{}, errors.New("secret manifest has no data")
+	}
+
+	return values, manifest.Metadata, nil
+}
+
+// parseJSONValues reads a flat JSON object. Numbers and booleans are taken as
+// their text, nested objects and arrays are rejected.
+func parseJSONValues(data string) (map[string]string, error) {
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON object: %w", err)
+	}
+
+	values := make(map[string]string, len(object))
+	for key, value := range object {
+		switch value := value.(type) {
+		case string:
+			values[key] = value
+		case json.Number, bool:
+			values[key] = fmt.Sprint(value)
+		case nil:
+			values[key] = ""
+		default:
+			return nil, fmt.Errorf("value of %q is not a string", key)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, errors.New("JSON object is empty")
+	}
+
+	return values, nil
+}
+
+// parseForm parses both URL encoded and multipart forms, the latter carry the
+// uploaded files.
+func parseForm(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+
+	err := r.ParseMultipartForm(maxRequestSize)
+	if errors.Is(err, http.ErrNotMultipart) {
+		return nil
+	}
+
+	return err
+}
+
+// readFileValues reads the uploaded files. The name of a file input is the
+// key prefixed by "file:", the file name is used when the key is empty.
+func readFileValues(form *multipart.Form) (map[string][]byte, error) {
+	values := make(map[string][]byte)
+	if form == nil {
+		return values, nil
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(form.File)) {
+		key, ok := strings.CutPrefix(name, fileFieldPrefix)
+		if !ok {
+			continue
+		}
+
+		for _, header := range form.File[name] {
+			fileKey := strings.TrimSpace(key)
+			if fileKey == "" {
+				fileKey = filepath.Base(header.Filename)
+			}
+
+			if header.Size > maxFileSize {
+				return nil, fmt.Errorf("file %s is larger than 1 MiB", header.Filename)
+			}
+
+			if _, ok := values[fileKey]; ok {
+				return nil, fmt.Errorf("key %s is given for more than one file", fileKey)
+			}
+
+			value, err := readFile(header)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file %s: %w", header.Filename, err)
+			}
+
+			values[fileKey] = value
+		}
+	}
+
+	return values, nil
+}
+
+func readFile(header *multipart.FileHeader) ([]byte, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// decodeBase64Values decodes the values of the given keys. Whitespace is
+// ignored, so wrapped base64 can be pasted as it is.
+func decodeBase64Values(values map[string][]byte, keys []string) error {
+	for _, key := range keys {
+		value, ok := values[key]
+		if !ok {
+			return fmt.Errorf("no value for base64 encoded key %s", key)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(value)), ""))
+		if err != nil {
+			return fmt.Errorf("value of %s is not base64 encoded", key)
+		}
+
+		values[key] = decoded
+	}
+
+	return nil
+}
+
+// parseEntries pairs the keys and values of the structured editor. Rows
+// without key and value are skipped, values are taken as they are apart from
+// the line breaks the browser submits.
+func parseEntries(keys, values []string) (map[string][]byte, error) {
+	if len(keys) != len(values) {
+		return nil, errors.New("every row needs a key and a value")
+	}
+
+	results := make(map[string][]byte, len(keys))
+	for i, key := range keys {
+		value := strings.ReplaceAll(values[i], "\r\n", "\n")
+		if key == "" && value == "" {
+			continue
+		}
+
+		if err := validateKey(key); err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+
+		if _, ok := results[key]; ok {
+			return nil, fmt.Errorf("row %d: duplicate key %q", i+1, key)
+		}
+
+		results[key] = []byte(value)
+	}
+
+	return results, nil
+}
+
+// validateKey checks the key against the rules of the Kubernetes API for
+// Secret keys.
+func validateKey(key string) error {
+	if key == "" {
+		return errors.New("empty key")
+	}
+
+	if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+		return fmt.Errorf("invalid key %q: %s", key, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// parseGenerators reads the rows of the generators. Rows without a key are
+// skipped unless they generate a key pair, the length, classes, subject and
+// SANs columns may be empty.
+func parseGenerators(form url.Values) ([]model.Generator, error) {
+	keys := form["generatorKey"]
+	for _, column := range []string{"generatorKind", "generatorLength", "generatorClasses", "generatorSubject", "generatorSANs", "generatorReveal"} {
+		if len(form[column]) != len(keys) {
+			return nil, errors.New("every generator needs a key, kind, length, classes, subject, SANs and reveal option")
+		}
+	}
+	kinds, lengths, classes, subjects, sans, reveals := form["generatorKind"], form["generatorLength"], form["generatorClasses"], form["generatorSubject"], form["generatorSANs"], form["generatorReveal"]
+
+	generators := []model.Generator{}
+	seen := make(map[string]struct{}, len(keys))
+	for i, key := range keys {
+		// key pairs have default keys, other rows without a key are empty
+		key = strings.TrimSpace(key)
+		keyPair := strings.HasPrefix(kinds[i], "ssh-") || kinds[i] == "tls"
+		if key == "" && !keyPair {
+			continue
+		}
+
+		if key != "" {
+			if err := validateKey(key); err != nil {
+				return nil, fmt.Errorf("generator %d: %w", i+1, err)
+			}
+
+			if _, ok := seen[key]; ok {
+				return nil, fmt.Errorf("generator %d: duplicate key %q", i+1, key)
+			}
+			seen[key] = struct{}{}
+		}
+
+		length := 0
+		if strings.TrimSpace(lengths[i]) != "" {
+			var err error
+			length, err = strconv.Atoi(strings.TrimSpace(lengths[i]))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("generator %d: invalid length %q", i+1, lengths[i])
+			}
+		}
+
+		generators = append(generators, model.Generator{
+			Key:     key,
+			Kind:    kinds[i],
+			Length:  length,
+			Classes: parseKeyList(classes[i]),
+			Subject: strings.TrimSpace(subjects[i]),
+			SANs:    parseKeyList(sans[i]),
+			Reveal:  reveals[i] == "true",
+		})
+	}
+
+	return generators, nil
+}
@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/atom363/sealed-secrets-ui/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseValues(t *testing.T) {
+	tcs := []struct {
+		name      string
+		data      string
+		format    string
+		want      parsedValues
+		isWantErr bool
+	}{
+		{
+			name: "detects key value pairs",
+			data: "API_TOKEN=token\nPG_PASSWORD=secret",
+			want: parsedValues{
+				format: "dotenv",
+				values: map[string]string{"API_TOKEN": "token", "PG_PASSWORD": "secret"},
+			},
+		},
+		{
+			name: "detects secret manifest",
+			data: `apiVersion: v1
+kind: Secret
+metadata:
+  name: api
+  namespace: payments
+data:
+  API_TOKEN: dG9rZW4=
+  PG_PASSWORD: b2xk
+stringData:
+  PG_PASSWORD: secret
+`,
+			want: parsedValues{
+				format:     "secret",
+				values:     map[string]string{"API_TOKEN": "token", "PG_PASSWORD": "secret"},
+				namespace:  "payments",
+				secretName: "api",
+			},
+		},
+		{
+			name: "detects JSON secret manifest",
+			data: `{"kind": "Secret", "metadata": {"name": "api"}, "data": {"API_TOKEN": "dG9rZW4="}}`,
+			want: parsedValues{
+				format:     "secret",
+				values:     map[string]string{"API_TOKEN": "token"},
+				secretName: "api",
+			},
+		},
+		{
+			name: "detects JSON object",
+			data: `{"API_TOKEN": "token", "PORT": 5432, "DEBUG": false}`,
+			want: parsedValues{
+				format: "json",
+				values: map[string]string{"API_TOKEN": "token", "PORT": "5432", "DEBUG": "false"},
+			},
+		},
+		{
+			name:      "explicit format is not detected",
+			data:      `{"API_TOKEN": "token"}`,
+			format:    "dotenv",
+			want:      parsedValues{format: "dotenv"},
+			isWantErr: true,
+		},
+		{
+			name:      "invalid base64 data",
+			data:      "kind: Secret\ndata:\n  API_TOKEN: not base64\n",
+			want:      parsedValues{format: "secret"},
+			isWantErr: true,
+		},
+		{
+			name:      "nested JSON object",
+			data:      `{"db": {"password": "secret"}}`,
+			want:      parsedValues{format: "json"},
+			isWantErr: true,
+		},
+		{
+			name:      "unknown format",
+			data:      "API_TOKEN=token",
+			format:    "xml",
+			want:      parsedValues{format: "xml"},
+			isWantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, gotErr := parseValues(tc.data, tc.format)
+			if tc.isWantErr {
+				assert.Error(t, gotErr)
+				assert.Equal(t, tc.want.format, got.format)
+				return
+			}
+
+			assert.NoError(t, gotErr)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestReadFileValues(t *testing.T) {
+	newForm := func(files map[string]map[string]string) *multipart.Form {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		for name, contents := range files {
+			for fileName, content := range contents {
+				part, err := writer.CreateFormFile(name, fileName)
+				require.NoError(t, err)
+				_, err = part.Write([]byte(content))
+				require.NoError(t, err)
+			}
+		}
+		require.NoError(t, writer.Close())
+
+		form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(maxRequestSize)
+		require.NoError(t, err)
+
+		return form
+	}
+
+	tcs := []struct {
+		name      string
+		files     map[string]map[string]string
+		want      map[string][]byte
+		isWantErr bool
+	}{
+		{
+			name: "key and file name",
+			files: map[string]map[string]string{
+				"file:keystore.jks": {"store.jks": "\x00\xfe\xed"},
+				"file:":             {"kubeconfig": "apiVersion: v1"},
+				"other":             {"ignored": "value"},
+			},
+			want: map[string][]byte{
+				"keystore.jks": []byte("\x00\xfe\xed"),
+				"kubeconfig":   []byte("apiVersion: v1"),
+			},
+		},
+		{
+			name: "duplicate key",
+			files: map[string]map[string]string{
+				"file:":       {"ca.crt": "a"},
+				"file:ca.crt": {"other.crt": "b"},
+			},
+			isWantErr: true,
+		},
+		{
+			name: "too large",
+			files: map[string]map[string]string{
+				"file:large": {"large.bin": strings.Repeat("a", maxFileSize+1)},
+			},
+			isWantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := readFileValues(newForm(tc.files))
+			if tc.isWantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDecodeBase64Values(t *testing.T) {
+	values := map[string][]byte{
+		"KEYSTORE": []byte("AP7t\n7Q=="),
+		"TOKEN":    []byte("token"),
+	}
+
+	require.NoError(t, decodeBase64Values(values, []string{"KEYSTORE"}))
+	assert.Equal(t, map[string][]byte{
+		"KEYSTORE": {0x00, 0xfe, 0xed, 0xed},
+		"TOKEN":    []byte("token"),
+	}, values)
+
+	assert.Error(t, decodeBase64Values(values, []string{"MISSING"}))
+	assert.Error(t, decodeBase64Values(values, []string{"TOKEN"}))
+}
+
+func TestParseEntries(t *testing.T) {
+	tcs := []struct {
+		name      string
+		keys      []string
+		values    []string
+		want      map[string][]byte
+		isWantErr bool
+	}{
+		{
+			name:   "values are kept as they are",
+			keys:   []string{"PRIVATE_KEY", "", "TOKEN"},
+			values: []string{"-----BEGIN-----\r\n`key`\r\n-----END-----\r\n", "", " token "},
+			want: map[string][]byte{
+				"PRIVATE_KEY": []byte("-----BEGIN-----\n`key`\n-----END-----\n"),
+				"TOKEN":       []byte(" token "),
+			},
+		},
+		{
+			name:      "empty key",
+			keys:      []string{""},
+			values:    []string{"value"},
+			isWantErr: true,
+		},
+		{
+			name:      "invalid key",
+			keys:      []string{"api token"},
+			values:    []string{"value"},
+			isWantErr: true,
+		},
+		{
+			name:      "duplicate key",
+			keys:      []string{"TOKEN", "TOKEN"},
+			values:    []string{"a", "b"},
+			isWantErr: true,
+		},
+		{
+			name:      "missing value",
+			keys:      []string{"TOKEN"},
+			isWantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseEntries(tc.keys, tc.values)
+			if tc.isWantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"API_TOKEN", ".dockerconfigjson", "tls.crt", "my-key"} {
+		assert.NoError(t, validateKey(key), key)
+	}
+
+	for _, key := range []string{"", "api token", "path/key", "..", strings.Repeat("a", 254)} {
+		assert.Error(t, validateKey(key), key)
+	}
+}
+
+func TestValidateKeyHandler(t *testing.T) {
+	tcs := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "value row",
+			query: "entryKey=api+token",
+			want:  "must consist of alphanumeric characters",
+		},
+		{
+			name:  "generator",
+			query: "generatorKey=api+token",
+			want:  "must consist of alphanumeric characters",
+		},
+		{
+			name:  "valid generator key",
+			query: "generatorKey=API_TOKEN",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/validate-key?"+tc.query, nil)
+			w := httptest.NewRecorder()
+
+			SealedSecretHandler{}.ValidateKeyHandler(w, r)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			if tc.want == "" {
+				assert.NotContains(t, w.Body.String(), "is-danger")
+			} else {
+				assert.Contains(t, w.Body.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestParseGenerators(t *testing.T) {
+	tcs := []struct {
+		name    string
+		form    url.Values
+		want    []model.Generator
+		wantErr string
+	}{
+		{
+			name: "rows",
+			form: url.Values{
+				"generatorKey":     {"DB_PASSWORD", "", "API_TOKEN"},
+				"generatorKind":    {"password", "password", "hex"},
+				"generatorLength":  {"24", "", ""},
+				"generatorClasses": {"lower, digits", "lower", ""},
+				"generatorSubject": {"", "", ""},
+				"generatorSANs":    {"", "", ""},
+				"generatorReveal":  {"false", "false", "true"},
+			},
+			want: []model.Generator{
+				{Key: "DB_PASSWORD", Kind: "password", Length: 24, Classes: []string{"lower", "digits"}, SANs: []string{}},
+				{Key: "API_TOKEN", Kind: "hex", Classes: []string{}, SANs: []string{}, Reveal: true},
+			},
+		},
+		{
+			name: "no rows",
+			form: url.Values{},
+			want: []model.Generator{},
+		},
+		{
+			name:    "missing columns",
+			form:    url.Values{"generatorKey": {"DB_PASSWORD"}},
+			wantErr: "every generator needs a key, kind, length, classes, subject, SANs and reveal option",
+		},
+		{
+			name: "invalid length",
+			form: url.Values{
+				"generatorKey":     {"DB_PASSWORD"},
+				"generatorKind":    {"password"},
+				"generatorLength":  {"long"},
+				"generatorClasses": {""},
+				"generatorSubject": {""},
+				"generatorSANs":    {""},
+				"generatorReveal":  {"false"},
+			},
+			wantErr: `generator 1: invalid length "long"`,
+		},
+		{
+			name: "duplicate key",
+			form: url.Values{
+				"generatorKey":     {"DB_PASSWORD", "DB_PASSWORD"},
+				"generatorKind":    {"password", "hex"},
+				"generatorLength":  {"", ""},
+				"generatorClasses": {"", ""},
+				"generatorSubject": {"", ""},
+				"generatorSANs":    {"", ""},
+				"generatorReveal":  {"false", "false"},
+			},
+			wantErr: `generator 2: duplicate key "DB_PASSWORD"`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseGenerators(tc.form)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseGeneratorsKeyPairs(t *testing.T) {
+	got, err := parseGenerators(url.Values{
+		"generatorKey":     {"", ""},
+		"generatorKind":    {"tls", "ssh-ed25519"},
+		"generatorLength":  {"90", ""},
+		"generatorClasses": {"", ""},
+		"generatorSubject": {" api ", "deploy"},
+		"generatorSANs":    {"api.payments.svc, 10.0.0.1", ""},
+		"generatorReveal":  {"false", "false"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.Generator{
+		{
+			Kind:    "tls",
+			Length:  90,
+			Classes: []string{},
+			Subject: "api",
+			SANs:    []string{"api.payments.svc", "10.0.0.1"},
+		},
+		{
+			Kind:    "ssh-ed25519",
+			Classes: []string{},
+			Subject: "deploy",
+			SANs:    []string{},
+		},
+	}, got)
+}
@@ -18,7 +18,6 @@ func NewRouter() http.Handler {
 	controllerNamespace := os.Getenv("SEALED_SECRETS_CONTROLLER_NAMESPACE")
 	controllerName := os.Getenv("SEALED_SECRETS_CONTROLLER_NAME")
 	clusterDomain := os.Getenv("CLUSTER_DOMAIN")
-	annotationsToPreserve := parseCSV(os.Getenv("SEALED_SECRETS_PRESERVE_ANNOTATIONS"))
 
 	if controllerNamespace == "" {
 		controllerNamespace = "kube-system" // default namespace if sealed-secrets was installed with Helm
@@ -32,7 +31,17 @@ func NewRouter() http.Handler {
 		clusterDomain = "cluster.local" // default cluster domain
 	}
 
-	svc, err := sealedsecret.NewSealedSecretService(controllerNamespace, controllerName, clusterDomain, annotationsToPreserve)
+	preserveConfig, err := sealedsecret.LoadPreserveConfig(os.Getenv("SEALED_SECRETS_PRESERVE_CONFIG"))
+	if err != nil {
+		log.Panic().Err(err).Msg("failed to load preserve config")
+	}
+
+	preserveConfig.Annotations = append(preserveConfig.Annotations, parseCSV(os.Getenv("SEALED_SECRETS_PRESERVE_ANNOTATIONS"))...)
+	preserveConfig.Labels = append(preserveConfig.Labels, parseCSV(os.Getenv("SEALED_SECRETS_PRESERVE_LABELS"))...)
+
+	lintErrors := parseCSV(os.Getenv("SEALED_SECRETS_LINT_ERRORS"))
+
+	svc, err := sealedsecret.NewSealedSecretService(controllerNamespace, controllerName, clusterDomain, preserveConfig, lintErrors)
 	if err != nil {
 		log.Panic().Err(err).Msg("failed to create sealed secret service")
 	}
@@ -42,9 +51,26 @@ func NewRouter() http.Handler {
 	mux := http.NewServeMux()
 	mux.Handle("/spinner.gif", http.FileServer(http.FS(assets.SpinnerFiles)))
 	mux.HandleFunc("/sealed-secret", handler.CreateSealedSecretHandler)
+	mux.HandleFunc("/sealed-secret/preview", handler.PreviewSealedSecretHandler)
+	mux.HandleFunc("/sealed-secret/adopt", handler.AdoptSecretHandler)
+	mux.HandleFunc("/sealed-secret/clone", handler.CloneSecretHandler)
+	mux.HandleFunc("/sealed-secret/merge", handler.MergeSealedSecretsHandler)
+	mux.HandleFunc("/sealed-secret/registry", handler.RegistrySecretHandler)
+	mux.HandleFunc("/parsed-values", handler.ParsedValuesHandler)
+	mux.HandleFunc("/value-entry", handler.ValueEntryHandler)
+	mux.HandleFunc("/generator-entry", handler.GeneratorEntryHandler)
+	mux.HandleFunc("/registry-entry", handler.RegistryEntryHandler)
+	mux.HandleFunc("/validate-key", handler.ValidateKeyHandler)
+	mux.HandleFunc("/secret-scope", handler.ScopeFieldHandler)
+	mux.HandleFunc("/secret-details", handler.SecretDetailsHandler)
+	mux.HandleFunc("/preserved-metadata", handler.PreservedMetadataHandler)
 	mux.HandleFunc("/namespaces", handler.NamespaceOptionsHandler)
 	mux.HandleFunc("/secrets", handler.SecretOptionsHandler)
 	mux.HandleFunc("/healthz", handlers.HealthHandler)
+	mux.Handle("/adopt", templ.Handler(ui.Adopt()))
+	mux.Handle("/clone", templ.Handler(ui.Clone()))
+	mux.Handle("/merge", templ.Handler(ui.Merge()))
+	mux.Handle("/registry", templ.Handler(ui.Registry()))
 	mux.Handle("/", templ.Handler(ui.Home()))
 
 	return mux
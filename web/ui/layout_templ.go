@@ -29,20 +29,20 @@ func Layout(title string) templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<html lang=\"en\"><head><meta charset=\"utf-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1, shrink-to-fit=no\"><meta name=\"theme-color\" content=\"#000000\"><script>\n\t\t\tfunction copyToClipboard() {\n\t\t\t\tvar copyText = document.getElementById(\"sealedSecretYaml\");\n\t\t\t\tcopyText.select();\n\t\t\t\tcopyText.setSelectionRange(0, 99999);\n\t\t\t\tdocument.execCommand(\"copy\");\n\t\t\t}\n\t\t</script><style>\n\t\t\t\t.token.number,\n\t\t\t\t.token.tag {\n\t\t\t\t  all: inherit;\n\t\t\t\t  color: hsl(14, 58%, 55%);\n\t\t\t\t}\n\t\t\t\t.loading-indicator {\n        \tdisplay:none;\n    \t\t}\n    \t\t.htmx-request .loading-indicator {\n        \tdisplay:inline;\n    \t\t}\n    \t\t.htmx-request.loading-indicator {\n        \tdisplay:inline;\n    \t\t}\n\t\t\t</style><link rel=\"stylesheet\" href=\"https://cdn.jsdelivr.net/npm/bulma@0.9.4/css/bulma.min.css\"><title>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<html lang=\"en\"><head><meta charset=\"utf-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1, shrink-to-fit=no\"><meta name=\"theme-color\" content=\"#000000\"><script>\n\t\t\tfunction copyToClipboard() {\n\t\t\t\tvar copyText = document.getElementById(\"sealedSecretYaml\");\n\t\t\t\tcopyText.select();\n\t\t\t\tcopyText.setSelectionRange(0, 99999);\n\t\t\t\tdocument.execCommand(\"copy\");\n\t\t\t}\n\t\t\tfunction toggleEntryValue(button) {\n\t\t\t\tconst value = button.closest(\".value-entry\").querySelector(\"textarea\");\n\t\t\t\tconst masked = value.classList.toggle(\"masked\");\n\t\t\t\tbutton.textContent = masked ? \"Reveal\" : \"Hide\";\n\t\t\t}\n\t\t\tfunction downloadValue(button) {\n\t\t\t\tconst value = button.closest(\".public-value\").querySelector(\"textarea\").value;\n\t\t\t\tconst link = document.createElement(\"a\");\n\t\t\t\tlink.href = URL.createObjectURL(new Blob([value], { type: \"text/plain\" }));\n\t\t\t\tlink.download = button.dataset.name;\n\t\t\t\tlink.click();\n\t\t\t\tURL.revokeObjectURL(link.href);\n\t\t\t}\n\t\t\tfunction setFileKey(input) {\n\t\t\t\tconst file = input.closest(\".file-value\").querySelector(\"input[type=file]\");\n\t\t\t\tfile.name = \"file:\" + input.value.trim();\n\t\t\t}\n\t\t\tfunction addFileValue() {\n\t\t\t\tconst row = document.querySelector(\".file-value\").cloneNode(true);\n\t\t\t\trow.querySelectorAll(\"input\").forEach(function(input) {\n\t\t\t\t\tinput.value = \"\";\n\t\t\t\t});\n\t\t\t\trow.querySelector(\"input[type=file]\").name = \"file:\";\n\t\t\t\tdocument.getElementById(\"file-values\").appendChild(row);\n\t\t\t}\n\t\t\tfunction loadFile(input, id) {\n\t\t\t\tif (input.files.length === 0) {\n\t\t\t\t\treturn;\n\t\t\t\t}\n\t\t\t\tinput.files[0].text().then(function(text) {\n\t\t\t\t\tconst target = document.getElementById(id);\n\t\t\t\t\ttarget.value = text;\n\t\t\t\t\ttarget.dispatchEvent(new Event(\"change\", { bubbles: true }));\n\t\t\t\t});\n\t\t\t}\n\t\t</script><style>\n\t\t\t\t.token.number,\n\t\t\t\t.token.tag {\n\t\t\t\t  all: inherit;\n\t\t\t\t  color: hsl(14, 58%, 55%);\n\t\t\t\t}\n\t\t\t\t.masked {\n\t\t\t\t\tfilter: blur(4px);\n\t\t\t\t}\n\t\t\t\t.loading-indicator {\n        \tdisplay:none;\n    \t\t}\n    \t\t.htmx-request .loading-indicator {\n        \tdisplay:inline;\n    \t\t}\n    \t\t.htmx-request.loading-indicator {\n        \tdisplay:inline;\n    \t\t}\n\t\t\t</style><link rel=\"stylesheet\" href=\"https://cdn.jsdelivr.net/npm/bulma@0.9.4/css/bulma.min.css\"><title>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		var templ_7745c5c3_Var2 string
 		templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(title)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/ui/layout.templ`, Line: 34, Col: 17}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/ui/layout.templ`, Line: 72, Col: 17}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "</title><script src=\"https://unpkg.com/htmx.org@2.0.1\" integrity=\"sha384-QWGpdj554B4ETpJJC9z+ZHJcA/i59TyjxEPXiiUgN2WmTyV5OEZWCD6gQhgkdpB/\" crossorigin=\"anonymous\"></script></head><body><div id=\"content\" class=\"container p-5 content\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "</title><script src=\"https://unpkg.com/htmx.org@2.0.1\" integrity=\"sha384-QWGpdj554B4ETpJJC9z+ZHJcA/i59TyjxEPXiiUgN2WmTyV5OEZWCD6gQhgkdpB/\" crossorigin=\"anonymous\"></script></head><body><div id=\"content\" class=\"container p-5 content\"><div class=\"tabs\"><ul><li><a href=\"/\">Seal</a></li><li><a href=\"/adopt\">Adopt</a></li><li><a href=\"/clone\">Clone</a></li><li><a href=\"/merge\">Merge</a></li><li><a href=\"/registry\">Registry</a></li></ul></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}